@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	_ Balancer = BalancerFunc(nil)
+	_ Balancer = (*roundRobinBalancer)(nil)
+	_ Balancer = (*leastInFlightBalancer)(nil)
+	_ Balancer = (*consistentHashBalancer)(nil)
+)
+
+type (
+	// Balancer picks an endpoint for the request.
+	Balancer interface {
+		// Pick picks one of the endpoints for the request, the endpoints are not empty.
+		// The returned Endpoint is matched with the endpoints by Addr, so it can be a wrapper of them.
+		Pick(r *http.Request, endpoints []Endpoint) Endpoint
+	}
+
+	BalancerFunc func(r *http.Request, endpoints []Endpoint) Endpoint
+
+	roundRobinBalancer struct {
+		next uint64
+	}
+
+	leastInFlightBalancer struct {
+		next uint64
+	}
+
+	consistentHashBalancer struct {
+		getKey func(r *http.Request) string
+	}
+)
+
+// NewRoundRobinBalancer picks the endpoints in turn.
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+// NewLeastInFlightBalancer picks the endpoint with the least in-flight requests, the ties are picked in turn.
+func NewLeastInFlightBalancer() Balancer {
+	return &leastInFlightBalancer{}
+}
+
+// NewConsistentHashBalancer picks the endpoint by the key of request with rendezvous hashing,
+// so the requests of the same key go to the same endpoint, and only the keys of the removed endpoint are remapped.
+// The key is default the url path.
+func NewConsistentHashBalancer(getKey func(r *http.Request) string) Balancer {
+	if getKey == nil {
+		getKey = func(r *http.Request) string {
+			return r.URL.Path
+		}
+	}
+	return &consistentHashBalancer{
+		getKey: getKey,
+	}
+}
+
+func (f BalancerFunc) Pick(r *http.Request, endpoints []Endpoint) Endpoint {
+	if f == nil {
+		return endpoints[0]
+	}
+	return f(r, endpoints)
+}
+
+func (b *roundRobinBalancer) Pick(_ *http.Request, endpoints []Endpoint) Endpoint {
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return endpoints[n%uint64(len(endpoints))]
+}
+
+func (b *leastInFlightBalancer) Pick(_ *http.Request, endpoints []Endpoint) Endpoint {
+	start := int((atomic.AddUint64(&b.next, 1) - 1) % uint64(len(endpoints)))
+
+	picked := endpoints[start]
+	for i := 1; i < len(endpoints); i++ {
+		e := endpoints[(start+i)%len(endpoints)]
+		if e.InFlight() < picked.InFlight() {
+			picked = e
+		}
+	}
+	return picked
+}
+
+func (b *consistentHashBalancer) Pick(r *http.Request, endpoints []Endpoint) Endpoint {
+	key := b.getKey(r)
+
+	var (
+		picked    Endpoint
+		maxWeight uint64
+	)
+	for _, e := range endpoints {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(e.Addr()))
+		if weight := h.Sum64(); picked == nil || weight > maxWeight {
+			picked, maxWeight = e, weight
+		}
+	}
+	return picked
+}
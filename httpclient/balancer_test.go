@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEndpoint struct {
+	addr     string
+	inFlight int64
+}
+
+func (e *testEndpoint) Addr() string    { return e.addr }
+func (e *testEndpoint) InFlight() int64 { return e.inFlight }
+func (*testEndpoint) Healthy() bool     { return true }
+
+func TestRoundRobinBalancer(t *testing.T) {
+	ast := assert.New(t)
+
+	endpoints := []Endpoint{&testEndpoint{addr: "a"}, &testEndpoint{addr: "b"}, &testEndpoint{addr: "c"}}
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	b := NewRoundRobinBalancer()
+	var picked []string
+	for i := 0; i < 6; i++ {
+		picked = append(picked, b.Pick(r, endpoints).Addr())
+	}
+	ast.Equal([]string{"a", "b", "c", "a", "b", "c"}, picked)
+}
+
+func TestLeastInFlightBalancer(t *testing.T) {
+	ast := assert.New(t)
+
+	endpoints := []Endpoint{
+		&testEndpoint{addr: "a", inFlight: 2},
+		&testEndpoint{addr: "b", inFlight: 1},
+		&testEndpoint{addr: "c", inFlight: 3},
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	b := NewLeastInFlightBalancer()
+	for i := 0; i < 3; i++ {
+		ast.Equal("b", b.Pick(r, endpoints).Addr())
+	}
+
+	// the ties are picked in turn
+	endpoints[1].(*testEndpoint).inFlight = 2
+	picked := map[string]int{}
+	for i := 0; i < 6; i++ {
+		picked[b.Pick(r, endpoints).Addr()]++
+	}
+	ast.Len(picked, 2)
+	ast.NotContains(picked, "c")
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	ast := assert.New(t)
+
+	endpoints := []Endpoint{&testEndpoint{addr: "a"}, &testEndpoint{addr: "b"}, &testEndpoint{addr: "c"}}
+	b := NewConsistentHashBalancer(func(r *http.Request) string {
+		return r.Header.Get("X-Key")
+	})
+
+	picked := map[string]string{}
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		key := fmt.Sprint(i)
+		r.Header.Set("X-Key", key)
+		addr := b.Pick(r, endpoints).Addr()
+		picked[key] = addr
+		counts[addr]++
+
+		ast.Equal(addr, b.Pick(r, endpoints).Addr(), "the same key is picked to the same endpoint")
+	}
+	ast.Len(counts, 3)
+
+	// only the keys of the removed endpoint are remapped
+	remaining := []Endpoint{endpoints[0], endpoints[2]}
+	for key, addr := range picked {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		r.Header.Set("X-Key", key)
+		if addr != "b" {
+			ast.Equal(addr, b.Pick(r, remaining).Addr(), key)
+		}
+	}
+
+	// default key is the path
+	b = NewConsistentHashBalancer(nil)
+	r1 := httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody)
+	r2 := httptest.NewRequest(http.MethodGet, "/users/1?k=v", http.NoBody)
+	ast.Equal(b.Pick(r1, endpoints).Addr(), b.Pick(r2, endpoints).Addr())
+}
+
+func TestBalancerFunc(t *testing.T) {
+	ast := assert.New(t)
+
+	endpoints := []Endpoint{&testEndpoint{addr: "a"}, &testEndpoint{addr: "b"}}
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	ast.Equal("a", BalancerFunc(nil).Pick(r, endpoints).Addr())
+	ast.Equal("b", BalancerFunc(func(_ *http.Request, endpoints []Endpoint) Endpoint {
+		return endpoints[len(endpoints)-1]
+	}).Pick(r, endpoints).Addr())
+}
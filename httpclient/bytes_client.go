@@ -1,3 +1,4 @@
+//go:generate mockgen -package httpclient -destination bytes_client_mock.go -source bytes_client.go BytesClient
 package httpclient
 
 import "github.com/go-resty/resty/v2"
@@ -30,35 +31,41 @@ func NewBytesClientRaw(cli Client) BytesClient {
 }
 
 func (c *defaultBytesClient) Get(urlPath string, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Get(urlPath, opts...))
+	resp, err := c.client.Get(urlPath, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
 func (c *defaultBytesClient) Post(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Post(urlPath, body, opts...))
+	resp, err := c.client.Post(urlPath, body, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
 func (c *defaultBytesClient) Put(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Put(urlPath, body, opts...))
+	resp, err := c.client.Put(urlPath, body, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
 func (c *defaultBytesClient) Patch(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Patch(urlPath, body, opts...))
+	resp, err := c.client.Patch(urlPath, body, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
 func (c *defaultBytesClient) Delete(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Delete(urlPath, body, opts...))
+	resp, err := c.client.Delete(urlPath, body, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
 func (c *defaultBytesClient) Execute(method, urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
-	return c.convertResponse(c.client.Execute(method, urlPath, body, opts...))
+	resp, err := c.client.Execute(method, urlPath, body, opts...)
+	return c.convertResponse(resp, err, opts)
 }
 
-func (*defaultBytesClient) convertResponse(resp *resty.Response, err error) ([]byte, error) {
+func (c *defaultBytesClient) convertResponse(resp *resty.Response, err error, opts []RequestOption) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
 
-	if err = NewResponseErrorNotSuccess(resp); err != nil {
+	if err = newResponseErrorNotSuccess(resp, requestOptionsOf(c.client, opts...)); err != nil {
 		return nil, err
 	}
 
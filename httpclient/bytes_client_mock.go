@@ -0,0 +1,154 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bytes_client.go
+
+// Package httpclient is a generated GoMock package.
+package httpclient
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBytesClient is a mock of BytesClient interface.
+type MockBytesClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockBytesClientMockRecorder
+}
+
+// MockBytesClientMockRecorder is the mock recorder for MockBytesClient.
+type MockBytesClientMockRecorder struct {
+	mock *MockBytesClient
+}
+
+// NewMockBytesClient creates a new mock instance.
+func NewMockBytesClient(ctrl *gomock.Controller) *MockBytesClient {
+	mock := &MockBytesClient{ctrl: ctrl}
+	mock.recorder = &MockBytesClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBytesClient) EXPECT() *MockBytesClientMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBytesClient) Delete(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBytesClientMockRecorder) Delete(urlPath, body interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBytesClient)(nil).Delete), varargs...)
+}
+
+// Execute mocks base method.
+func (m *MockBytesClient) Execute(method, urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{method, urlPath, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Execute", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Execute indicates an expected call of Execute.
+func (mr *MockBytesClientMockRecorder) Execute(method, urlPath, body interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{method, urlPath, body}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockBytesClient)(nil).Execute), varargs...)
+}
+
+// Get mocks base method.
+func (m *MockBytesClient) Get(urlPath string, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBytesClientMockRecorder) Get(urlPath interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBytesClient)(nil).Get), varargs...)
+}
+
+// Patch mocks base method.
+func (m *MockBytesClient) Patch(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Patch", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockBytesClientMockRecorder) Patch(urlPath, body interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockBytesClient)(nil).Patch), varargs...)
+}
+
+// Post mocks base method.
+func (m *MockBytesClient) Post(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Post", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockBytesClientMockRecorder) Post(urlPath, body interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockBytesClient)(nil).Post), varargs...)
+}
+
+// Put mocks base method.
+func (m *MockBytesClient) Put(urlPath string, body interface{}, opts ...RequestOption) ([]byte, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Put", varargs...)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockBytesClientMockRecorder) Put(urlPath, body interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockBytesClient)(nil).Put), varargs...)
+}
@@ -0,0 +1,338 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCacheMaxBytes is the max size of the default store.
+	DefaultCacheMaxBytes = 64 * 1024 * 1024
+	// DefaultCacheMaxEntryBytes is the max size of the response body to store.
+	DefaultCacheMaxEntryBytes = 1024 * 1024
+
+	// CacheStatusHeader is the response header which tells how the response is served by the cache.
+	CacheStatusHeader = "X-Cache-Status"
+	// CacheHit means the response is served from the cache without requesting.
+	CacheHit = "HIT"
+	// CacheMiss means the response is from the server, and it's stored if it's cacheable.
+	CacheMiss = "MISS"
+	// CacheRevalidated means the response is served from the cache after the server responds 304 Not Modified.
+	CacheRevalidated = "REVALIDATED"
+)
+
+var _ http.RoundTripper = (*cacheTransport)(nil)
+
+type (
+	CacheParams struct {
+		// Store stores the responses, default is NewMemoryCacheStore with DefaultCacheMaxBytes.
+		Store CacheStore
+		// GetKey returns the key of the request, default is DefaultCacheKey.
+		GetKey func(r *http.Request) string
+		// MaxEntryBytes is the max size of the response body to store, default is DefaultCacheMaxEntryBytes.
+		// The larger responses are streamed to the caller without storing.
+		MaxEntryBytes int64
+	}
+
+	cacheTransport struct {
+		params CacheParams
+		next   http.RoundTripper
+	}
+
+	// cacheBody is the body of the response which is partially read.
+	cacheBody struct {
+		io.Reader
+		io.Closer
+	}
+
+	cacheEntry struct {
+		StoredAt time.Time `json:"storedAt"`
+		// VaryHeader is the request header selected by the Vary of response.
+		VaryHeader http.Header `json:"varyHeader,omitempty"`
+		// Response is the dumped response including the body.
+		Response []byte `json:"response"`
+	}
+)
+
+// WithCache caches the responses of GET requests as a private cache, see RFC 7234.
+// The fresh responses are determined by the Cache-Control max-age and Expires, and the Vary is respected.
+// The stale responses are revalidated with the conditional requests by ETag and Last-Modified,
+// and the responses without the freshness information are always revalidated.
+// The successful requests of unsafe methods invalidate the cached response of the url.
+// It only takes effect for NewClient, see CacheStatusHeader for the cache status of the response.
+func WithCache(params CacheParams) RequestOption {
+	if params.Store == nil {
+		params.Store = NewMemoryCacheStore(DefaultCacheMaxBytes)
+	}
+	if params.GetKey == nil {
+		params.GetKey = DefaultCacheKey
+	}
+	if params.MaxEntryBytes <= 0 {
+		params.MaxEntryBytes = DefaultCacheMaxEntryBytes
+	}
+
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &cacheTransport{params: params, next: rt}
+		})
+	}
+}
+
+// DefaultCacheKey returns the url with the hash of Authorization and Cookie headers,
+// so the responses are not shared among the different credentials.
+func DefaultCacheKey(r *http.Request) string {
+	authorization, cookie := r.Header.Values("Authorization"), r.Header.Values("Cookie")
+	if len(authorization) == 0 && len(cookie) == 0 {
+		return r.URL.String()
+	}
+	sum := sha256.Sum256([]byte(strings.Join(authorization, ",") + "\n" + strings.Join(cookie, ",")))
+	return r.URL.String() + " " + hex.EncodeToString(sum[:])
+}
+
+func (t *cacheTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := t.params.GetKey(r)
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" {
+		resp, err := t.next.RoundTrip(r)
+		if err == nil && !isSafeMethod(r.Method) && resp.StatusCode < http.StatusBadRequest {
+			t.params.Store.Delete(key)
+		}
+		return resp, err
+	}
+
+	reqCacheControl := parseCacheControl(r.Header)
+	if _, ok := reqCacheControl["no-store"]; ok {
+		return t.next.RoundTrip(r)
+	}
+
+	entry, cached, ok := t.load(key, r)
+	if ok {
+		if t.isFresh(entry, cached, reqCacheControl) {
+			cached.Header.Set(CacheStatusHeader, CacheHit)
+			return cached, nil
+		}
+
+		etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			req := r.Clone(r.Context())
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+			r = req
+		} else {
+			ok = false
+		}
+	}
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, downloadErrorMaxSize))
+		_ = resp.Body.Close()
+
+		for k, values := range resp.Header {
+			if k != "Content-Length" {
+				cached.Header[k] = values
+			}
+		}
+		cached.Header.Del(CacheStatusHeader)
+		if err = t.store(key, r, cached); err != nil {
+			return nil, err
+		}
+		cached.Header.Set(CacheStatusHeader, CacheRevalidated)
+		return cached, nil
+	}
+	if ok {
+		_ = cached.Body.Close()
+	}
+
+	if isCacheable(resp) {
+		if err = t.store(key, r, resp); err != nil {
+			return nil, err
+		}
+	}
+	resp.Header.Set(CacheStatusHeader, CacheMiss)
+	return resp, nil
+}
+
+// load returns the cached response matched the Vary of the request.
+func (t *cacheTransport) load(key string, r *http.Request) (*cacheEntry, *http.Response, bool) {
+	data, ok := t.params.Store.Get(key)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil, false
+	}
+	for k, values := range entry.VaryHeader {
+		if strings.Join(r.Header.Values(k), ",") != strings.Join(values, ",") {
+			return nil, nil, false
+		}
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.Response)), r)
+	if err != nil {
+		return nil, nil, false
+	}
+	return &entry, resp, true
+}
+
+// store stores the response and the request header selected by Vary, the body of resp is read and replaced.
+// The response whose body is larger than MaxEntryBytes is not stored, and the body read is put back.
+func (t *cacheTransport) store(key string, r *http.Request, resp *http.Response) error {
+	if resp.ContentLength > t.params.MaxEntryBytes {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.params.MaxEntryBytes+1))
+	if err != nil {
+		_ = resp.Body.Close()
+		return err
+	}
+	if int64(len(body)) > t.params.MaxEntryBytes {
+		resp.Body = &cacheBody{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return nil
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	dumped, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+
+	entry := &cacheEntry{
+		StoredAt: time.Now(),
+		Response: dumped,
+	}
+	for _, field := range resp.Header.Values("Vary") {
+		for _, k := range strings.Split(field, ",") {
+			if k = http.CanonicalHeaderKey(strings.TrimSpace(k)); k != "" {
+				if entry.VaryHeader == nil {
+					entry.VaryHeader = http.Header{}
+				}
+				entry.VaryHeader[k] = r.Header.Values(k)
+			}
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	t.params.Store.Set(key, data)
+	return nil
+}
+
+// isFresh reports whether the cached response can be served without revalidation.
+func (*cacheTransport) isFresh(entry *cacheEntry, resp *http.Response, reqCacheControl map[string]string) bool {
+	if _, ok := reqCacheControl["no-cache"]; ok {
+		return false
+	}
+	respCacheControl := parseCacheControl(resp.Header)
+	if _, ok := respCacheControl["no-cache"]; ok {
+		return false
+	}
+
+	age := time.Since(entry.StoredAt)
+	if v, err := strconv.Atoi(resp.Header.Get("Age")); err == nil && v > 0 {
+		age += time.Duration(v) * time.Second
+	}
+	if maxAge, ok := parseCacheControlSeconds(reqCacheControl, "max-age"); ok && age > maxAge {
+		return false
+	}
+
+	var lifetime time.Duration
+	if maxAge, ok := parseCacheControlSeconds(respCacheControl, "max-age"); ok {
+		lifetime = maxAge
+	} else if expires := resp.Header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return false
+		}
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			date = entry.StoredAt
+		}
+		lifetime = expiresAt.Sub(date)
+	}
+	return age < lifetime
+}
+
+// isCacheable reports whether the response can be stored, which is fresh for a while or can be revalidated.
+func isCacheable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMultipleChoices,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+
+	cacheControl := parseCacheControl(resp.Header)
+	if _, ok := cacheControl["no-store"]; ok {
+		return false
+	}
+	for _, field := range resp.Header.Values("Vary") {
+		if strings.TrimSpace(field) == "*" {
+			return false
+		}
+	}
+	if _, ok := cacheControl["max-age"]; ok {
+		return true
+	}
+	return resp.Header.Get("Expires") != "" || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// parseCacheControl parses the Cache-Control header into the directives and their values.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, field := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(field, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if index := strings.Index(part, "="); index >= 0 {
+				name, value = part[:index], strings.Trim(part[index+1:], `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = value
+		}
+	}
+	return directives
+}
+
+func parseCacheControlSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	v, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
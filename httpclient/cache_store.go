@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	_ CacheStore = (*memoryCacheStore)(nil)
+	_ CacheStore = (*diskCacheStore)(nil)
+)
+
+type (
+	// CacheStore stores the cached responses, it's best-effort so the errors are ignored.
+	CacheStore interface {
+		Get(key string) ([]byte, bool)
+		Set(key string, value []byte)
+		Delete(key string)
+	}
+
+	memoryCacheStore struct {
+		maxBytes int64
+		mu       sync.Mutex
+		size     int64
+		lru      *list.List
+		items    map[string]*list.Element
+	}
+
+	memoryCacheItem struct {
+		key   string
+		value []byte
+	}
+
+	diskCacheStore struct {
+		dir string
+	}
+)
+
+// NewMemoryCacheStore creates the in-memory store which evicts the least recently used items
+// once the size of keys and values exceeds maxBytes.
+func NewMemoryCacheStore(maxBytes int64) CacheStore {
+	return &memoryCacheStore{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// NewDiskCacheStore creates the store which saves each item as a file in the directory.
+func NewDiskCacheStore(dir string) CacheStore {
+	return &diskCacheStore{
+		dir: dir,
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+	size := int64(len(key) + len(value))
+	if size > s.maxBytes {
+		return
+	}
+	s.items[key] = s.lru.PushFront(&memoryCacheItem{key: key, value: value})
+	s.size += size
+	for s.size > s.maxBytes {
+		s.remove(s.lru.Back().Value.(*memoryCacheItem).key)
+	}
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+}
+
+func (s *memoryCacheStore) remove(key string) {
+	elem, ok := s.items[key]
+	if !ok {
+		return
+	}
+	item := s.lru.Remove(elem).(*memoryCacheItem)
+	delete(s.items, key)
+	s.size -= int64(len(item.key) + len(item.value))
+}
+
+func (s *diskCacheStore) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(s.filename(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *diskCacheStore) Set(key string, value []byte) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil { //nolint:gomnd
+		return
+	}
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.filename(key)) // replace atomically
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+func (s *diskCacheStore) Delete(key string) {
+	_ = os.Remove(s.filename(key))
+}
+
+func (s *diskCacheStore) filename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheStore(t *testing.T) {
+	ast := assert.New(t)
+
+	s := NewMemoryCacheStore(10)
+	_, ok := s.Get("a")
+	ast.False(ok)
+
+	s.Set("a", []byte("111"))
+	s.Set("b", []byte("222"))
+	v, ok := s.Get("a")
+	ast.True(ok)
+	ast.Equal("111", string(v))
+
+	// b is the least recently used
+	s.Set("c", []byte("333"))
+	_, ok = s.Get("b")
+	ast.False(ok)
+	_, ok = s.Get("a")
+	ast.True(ok)
+	_, ok = s.Get("c")
+	ast.True(ok)
+
+	// replaced
+	s.Set("a", []byte("1"))
+	v, _ = s.Get("a")
+	ast.Equal("1", string(v))
+	ast.EqualValues(6, s.(*memoryCacheStore).size)
+
+	// too large to store
+	s.Set("d", []byte("4444444444"))
+	_, ok = s.Get("d")
+	ast.False(ok)
+
+	s.Delete("a")
+	_, ok = s.Get("a")
+	ast.False(ok)
+	s.Delete("not-exist")
+	ast.EqualValues(4, s.(*memoryCacheStore).size)
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	ast := assert.New(t)
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	s := NewDiskCacheStore(dir)
+	_, ok := s.Get("a")
+	ast.False(ok)
+
+	s.Set("a", []byte("111"))
+	s.Set("http://host/path?k=v", []byte("222"))
+	v, ok := s.Get("a")
+	ast.True(ok)
+	ast.Equal("111", string(v))
+	v, ok = NewDiskCacheStore(dir).Get("http://host/path?k=v")
+	ast.True(ok)
+	ast.Equal("222", string(v))
+
+	s.Set("a", []byte("1"))
+	v, _ = s.Get("a")
+	ast.Equal("1", string(v))
+
+	s.Delete("a")
+	_, ok = s.Get("a")
+	ast.False(ok)
+
+	entries, err := os.ReadDir(dir)
+	ast.NoError(err)
+	ast.Len(entries, 1, "no temp files are left")
+}
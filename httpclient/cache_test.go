@@ -0,0 +1,232 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCache(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls   int32
+		version int32 = 1
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		v := atomic.LoadInt32(&version)
+		etag := fmt.Sprintf(`"v%d"`, v)
+
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/expired":
+			w.Header().Set("Cache-Control", "max-age=0")
+		case "/expires":
+			w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		case "/etag":
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/last-modified":
+			lastModified := time.Date(2020, 1, int(v), 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+			w.Header().Set("Last-Modified", lastModified)
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/error":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = fmt.Fprintf(w, "%s %d %s", r.URL.Path, v, r.Header.Get("Accept-Language"))
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCache(CacheParams{}))
+	get := func(urlPath string, opts ...RequestOption) (string, string) {
+		resp, err := c.Get(urlPath, opts...)
+		ast.NoError(err)
+		return string(resp.Body()), resp.Header().Get(CacheStatusHeader)
+	}
+	assertCalls := func(path string, expected int32) {
+		ast.Equal(expected, atomic.SwapInt32(&calls, 0), path)
+	}
+
+	for _, path := range []string{"/max-age", "/expires"} {
+		body, status := get(path)
+		ast.Equal(path+" 1 ", body)
+		ast.Equal(CacheMiss, status)
+		atomic.StoreInt32(&version, 2)
+		body, status = get(path)
+		ast.Equal(path+" 1 ", body)
+		ast.Equal(CacheHit, status)
+		assertCalls(path, 1)
+
+		// the request no-cache forces revalidation
+		body, status = get(path, WithHeader("Cache-Control", "no-cache"))
+		ast.Equal(path+" 2 ", body)
+		ast.Equal(CacheMiss, status)
+		assertCalls(path, 1)
+		atomic.StoreInt32(&version, 1)
+	}
+
+	for _, path := range []string{"/etag", "/last-modified"} {
+		body, status := get(path)
+		ast.Equal(path+" 1 ", body)
+		ast.Equal(CacheMiss, status)
+		body, status = get(path)
+		ast.Equal(path+" 1 ", body)
+		ast.Equal(CacheRevalidated, status)
+		assertCalls(path, 2)
+
+		atomic.StoreInt32(&version, 2)
+		body, status = get(path)
+		ast.Equal(path+" 2 ", body)
+		ast.Equal(CacheMiss, status)
+		body, status = get(path)
+		ast.Equal(path+" 2 ", body)
+		ast.Equal(CacheRevalidated, status)
+		assertCalls(path, 2)
+		atomic.StoreInt32(&version, 1)
+	}
+
+	// vary
+	body, _ := get("/vary", WithHeader("Accept-Language", "en"))
+	ast.Equal("/vary 1 en", body)
+	body, status := get("/vary", WithHeader("Accept-Language", "en"))
+	ast.Equal("/vary 1 en", body)
+	ast.Equal(CacheHit, status)
+	body, status = get("/vary", WithHeader("Accept-Language", "zh"))
+	ast.Equal("/vary 1 zh", body)
+	ast.Equal(CacheMiss, status)
+	assertCalls("/vary", 2)
+
+	// not cacheable
+	for _, path := range []string{"/expired", "/no-store", "/error", "/none"} {
+		get(path)
+		_, status = get(path)
+		ast.Equal(CacheMiss, status, path)
+		assertCalls(path, 2)
+	}
+	get("/max-age", WithHeader("Cache-Control", "no-store"))
+	assertCalls("/max-age", 1)
+
+	// the unsafe methods invalidate the cache
+	_, status = get("/max-age")
+	ast.Equal(CacheHit, status)
+	_, err := c.Post("/max-age", "body")
+	ast.NoError(err)
+	_, status = get("/max-age")
+	ast.Equal(CacheMiss, status)
+	assertCalls("/max-age", 2)
+}
+
+func TestWithCacheKeyAndSize(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		switch r.URL.Path {
+		case "/large":
+			_, _ = io.WriteString(w, strings.Repeat("x", 100))
+		case "/large-chunked":
+			for i := 0; i < 10; i++ {
+				_, _ = io.WriteString(w, strings.Repeat("x", 10))
+				w.(http.Flusher).Flush()
+			}
+		default:
+			_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+		}
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCache(CacheParams{MaxEntryBytes: 50}))
+
+	// the responses are not shared among the credentials
+	for _, token := range []string{"t1", "t2", "t1"} {
+		resp, err := c.Get("/", WithAuthToken(token))
+		ast.NoError(err)
+		ast.Equal("Bearer "+token, string(resp.Body()))
+	}
+	ast.EqualValues(2, atomic.LoadInt32(&calls))
+
+	// the large responses are not stored
+	for _, path := range []string{"/large", "/large-chunked"} {
+		atomic.StoreInt32(&calls, 0)
+		for i := 0; i < 2; i++ {
+			resp, err := c.Get(path)
+			ast.NoError(err)
+			ast.Equal(strings.Repeat("x", 100), string(resp.Body()))
+			ast.Equal(CacheMiss, resp.Header().Get(CacheStatusHeader))
+		}
+		ast.EqualValues(2, atomic.LoadInt32(&calls))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://host/p", http.NoBody)
+	ast.Equal("http://host/p", DefaultCacheKey(r))
+	r.Header.Set("Cookie", "session=s")
+	ast.NotEqual("http://host/p", DefaultCacheKey(r))
+	ast.NotContains(DefaultCacheKey(r), "session")
+}
+
+func TestWithCacheDiskStore(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_, _ = io.WriteString(w, `{"name":"n"}`)
+	}))
+	defer testServer.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		c := NewObjectClient(testServer.URL, WithCache(CacheParams{Store: NewDiskCacheStore(dir)}))
+		var obj struct {
+			Name string `json:"name"`
+		}
+		ast.NoError(c.Get("/", &obj))
+		ast.Equal("n", obj.Name)
+	}
+	ast.EqualValues(1, atomic.LoadInt32(&calls))
+}
+
+func TestParseCacheControl(t *testing.T) {
+	ast := assert.New(t)
+
+	header := http.Header{}
+	header.Add("Cache-Control", `public, Max-Age=60, no-cache="Set-Cookie"`)
+	header.Add("Cache-Control", "must-revalidate")
+	ast.Equal(map[string]string{
+		"public":          "",
+		"max-age":         "60",
+		"no-cache":        "Set-Cookie",
+		"must-revalidate": "",
+	}, parseCacheControl(header))
+
+	d, ok := parseCacheControlSeconds(parseCacheControl(header), "max-age")
+	ast.True(ok)
+	ast.Equal(time.Minute, d)
+	_, ok = parseCacheControlSeconds(map[string]string{"max-age": "x"}, "max-age")
+	ast.False(ok)
+}
@@ -0,0 +1,299 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+const (
+	DefaultCircuitBreakerFailureThreshold    = 5
+	DefaultCircuitBreakerOpenTimeout         = 30 * time.Second
+	DefaultCircuitBreakerHalfOpenMaxRequests = 1
+)
+
+var (
+	_ CircuitOpenError  = (*circuitOpenError)(nil)
+	_ http.RoundTripper = (*circuitBreakerTransport)(nil)
+
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+
+	// DefaultCircuitBreakerFailureStatusClasses treats the 5xx responses as failures.
+	DefaultCircuitBreakerFailureStatusClasses = []int{5}
+)
+
+type (
+	// CircuitState is the state of a circuit.
+	CircuitState int
+
+	CircuitBreakerParams struct {
+		// FailureThreshold is the number of consecutive failures to open the circuit,
+		// default is DefaultCircuitBreakerFailureThreshold.
+		FailureThreshold int
+		// OpenTimeout is the duration of the open state before half-open, default is DefaultCircuitBreakerOpenTimeout.
+		OpenTimeout time.Duration
+		// HalfOpenMaxRequests is the number of trial requests in the half-open state,
+		// the circuit is closed once all of them succeed, default is DefaultCircuitBreakerHalfOpenMaxRequests.
+		HalfOpenMaxRequests int
+		// FailureStatusClasses are the status classes treated as failures, such as 5 for 5xx,
+		// default is DefaultCircuitBreakerFailureStatusClasses.
+		FailureStatusClasses []int
+		// SlowCallThreshold treats the responses slower than it as failures, default is 0 means disabled.
+		SlowCallThreshold time.Duration
+		// IsFailure reports whether the call is a failure, it overrides FailureStatusClasses and SlowCallThreshold.
+		// The call canceled by the caller is neither a failure nor a success, so it's not reported.
+		IsFailure func(resp *http.Response, err error, latency time.Duration) bool
+		// GetKey returns the key of the circuit for the request, default is the host of request url.
+		GetKey func(r *http.Request) string
+		// OnStateChange is called when the state of a circuit is changed.
+		OnStateChange func(key string, from, to CircuitState)
+	}
+
+	CircuitOpenError interface {
+		error
+		GetKey() string
+	}
+
+	circuitOpenError struct {
+		key string
+	}
+
+	circuitBreaker struct {
+		params   CircuitBreakerParams
+		mu       sync.Mutex
+		circuits map[string]*circuit
+	}
+
+	circuit struct {
+		state             CircuitState
+		generation        uint64
+		failures          int
+		openedAt          time.Time
+		halfOpenRequests  int
+		halfOpenSuccesses int
+	}
+
+	circuitBreakerTransport struct {
+		breaker *circuitBreaker
+		next    http.RoundTripper
+	}
+)
+
+// WithCircuitBreaker breaks the calls to the unhealthy upstreams, the circuits are tracked per host by default.
+// The requests are failed with CircuitOpenError without being sent when the circuit is open.
+// It wraps the transport, so it only takes effect for NewClient, and each retry attempt is counted.
+func WithCircuitBreaker(params CircuitBreakerParams) RequestOption { //nolint:gocritic
+	if params.FailureThreshold <= 0 {
+		params.FailureThreshold = DefaultCircuitBreakerFailureThreshold
+	}
+	if params.OpenTimeout <= 0 {
+		params.OpenTimeout = DefaultCircuitBreakerOpenTimeout
+	}
+	if params.HalfOpenMaxRequests <= 0 {
+		params.HalfOpenMaxRequests = DefaultCircuitBreakerHalfOpenMaxRequests
+	}
+	if params.FailureStatusClasses == nil {
+		params.FailureStatusClasses = DefaultCircuitBreakerFailureStatusClasses
+	}
+	if params.GetKey == nil {
+		params.GetKey = func(r *http.Request) string {
+			return r.URL.Host
+		}
+	}
+
+	breaker := &circuitBreaker{
+		params:   params,
+		circuits: map[string]*circuit{},
+	}
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &circuitBreakerTransport{breaker: breaker, next: rt}
+		})
+	}
+}
+
+func NewCircuitOpenError(key string) error {
+	return errors.WithStack(&circuitOpenError{key: key})
+}
+
+func AsCircuitOpenError(err error) (CircuitOpenError, bool) {
+	if e := new(circuitOpenError); errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+func IsCircuitOpenError(err error) bool {
+	_, ok := AsCircuitOpenError(err)
+	return ok
+}
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+func (e *circuitOpenError) GetKey() string {
+	return e.key
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s", e.key, ErrCircuitOpen.Error())
+}
+
+func (*circuitOpenError) Unwrap() error { return ErrCircuitOpen }
+
+func (t *circuitBreakerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := t.breaker.params.GetKey(r)
+	generation, ok := t.breaker.allow(key)
+	if !ok {
+		closeRequestBody(r)
+		return nil, NewCircuitOpenError(key)
+	}
+
+	startTime := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	if err != nil && errors.Is(err, context.Canceled) {
+		t.breaker.release(key, generation)
+		return resp, err
+	}
+	t.breaker.report(key, generation, t.breaker.isFailure(resp, err, time.Since(startTime)))
+	return resp, err
+}
+
+// allow reports whether the request is allowed, and returns the generation of the circuit.
+func (b *circuitBreaker) allow(key string) (uint64, bool) {
+	b.mu.Lock()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	var changed func()
+	allowed := true
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.params.OpenTimeout {
+			allowed = false
+			break
+		}
+		changed = b.setState(key, c, CircuitHalfOpen)
+		c.halfOpenRequests++
+	case CircuitHalfOpen:
+		if c.halfOpenRequests >= b.params.HalfOpenMaxRequests {
+			allowed = false
+			break
+		}
+		c.halfOpenRequests++
+	}
+	generation := c.generation
+	b.mu.Unlock()
+
+	if changed != nil {
+		changed()
+	}
+	return generation, allowed
+}
+
+// report records the result of the request, the stale results of the previous generations are ignored.
+func (b *circuitBreaker) report(key string, generation uint64, failure bool) {
+	b.mu.Lock()
+	c := b.circuits[key]
+	if c.generation != generation {
+		b.mu.Unlock()
+		return
+	}
+
+	var changed func()
+	switch c.state {
+	case CircuitClosed:
+		if !failure {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= b.params.FailureThreshold {
+			changed = b.setState(key, c, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failure {
+			changed = b.setState(key, c, CircuitOpen)
+			break
+		}
+		c.halfOpenSuccesses++
+		if c.halfOpenSuccesses >= b.params.HalfOpenMaxRequests {
+			changed = b.setState(key, c, CircuitClosed)
+		}
+	}
+	b.mu.Unlock()
+
+	if changed != nil {
+		changed()
+	}
+}
+
+// release releases the half-open slot of the request which is not reported, such as it's canceled.
+func (b *circuitBreaker) release(key string, generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[key]
+	if c.generation == generation && c.state == CircuitHalfOpen && c.halfOpenRequests > 0 {
+		c.halfOpenRequests--
+	}
+}
+
+// setState changes the state of c, and returns the callback to be called without lock.
+func (b *circuitBreaker) setState(key string, c *circuit, state CircuitState) func() {
+	from := c.state
+	*c = circuit{
+		state:      state,
+		generation: c.generation + 1,
+	}
+	if state == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+
+	if b.params.OnStateChange == nil {
+		return nil
+	}
+	return func() {
+		b.params.OnStateChange(key, from, state)
+	}
+}
+
+func (b *circuitBreaker) isFailure(resp *http.Response, err error, latency time.Duration) bool {
+	if b.params.IsFailure != nil {
+		return b.params.IsFailure(resp, err, latency)
+	}
+	if err != nil {
+		return true
+	}
+	if b.params.SlowCallThreshold > 0 && latency > b.params.SlowCallThreshold {
+		return true
+	}
+	for _, class := range b.params.FailureStatusClasses {
+		if resp.StatusCode/100 == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var (
+		ast        = assert.New(t)
+		requests   int32
+		statusCode int32 = http.StatusServiceUnavailable
+		mu         sync.Mutex
+		changes    []CircuitState
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&statusCode)))
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCircuitBreaker(CircuitBreakerParams{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		OnStateChange: func(key string, from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			ast.Equal(testServer.Listener.Addr().String(), key)
+			changes = append(changes, to)
+		},
+	}))
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get("/")
+		ast.NoError(err)
+		ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	}
+
+	_, err := c.Get("/")
+	ast.ErrorIs(err, ErrCircuitOpen)
+	ast.True(IsCircuitOpenError(err))
+	if e, ok := AsCircuitOpenError(err); ast.True(ok) {
+		ast.Equal(testServer.Listener.Addr().String(), e.GetKey())
+	}
+	ast.EqualValues(2, atomic.LoadInt32(&requests))
+
+	// half-open failure reopens the circuit
+	time.Sleep(60 * time.Millisecond)
+	_, err = c.Get("/")
+	ast.NoError(err)
+	_, err = c.Get("/")
+	ast.ErrorIs(err, ErrCircuitOpen)
+	ast.EqualValues(3, atomic.LoadInt32(&requests))
+
+	// half-open success closes the circuit
+	atomic.StoreInt32(&statusCode, http.StatusOK)
+	time.Sleep(60 * time.Millisecond)
+	_, err = c.Get("/")
+	ast.NoError(err)
+	_, err = c.Get("/")
+	ast.NoError(err)
+	ast.EqualValues(5, atomic.LoadInt32(&requests))
+
+	mu.Lock()
+	ast.Equal([]CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}, changes)
+	mu.Unlock()
+
+	// the retry stops at the open circuit
+	atomic.StoreInt32(&statusCode, http.StatusServiceUnavailable)
+	atomic.StoreInt32(&requests, 0)
+	_, err = c.Get("/", WithRetry(RetryPolicy{MaxAttempts: 5, InitialInterval: time.Millisecond}))
+	ast.ErrorIs(err, ErrCircuitOpen)
+	ast.EqualValues(2, atomic.LoadInt32(&requests))
+}
+
+func TestCircuitBreakerIsFailure(t *testing.T) {
+	ast := assert.New(t)
+
+	b := &circuitBreaker{params: CircuitBreakerParams{
+		FailureStatusClasses: DefaultCircuitBreakerFailureStatusClasses,
+		SlowCallThreshold:    time.Second,
+	}}
+	ast.True(b.isFailure(nil, errors.New("connection refused"), 0))
+	ast.False(b.isFailure(&http.Response{StatusCode: http.StatusOK}, nil, 0))
+	ast.False(b.isFailure(&http.Response{StatusCode: http.StatusNotFound}, nil, 0))
+	ast.True(b.isFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil, 0))
+	ast.True(b.isFailure(&http.Response{StatusCode: http.StatusOK}, nil, 2*time.Second))
+
+	b.params.FailureStatusClasses = []int{4, 5}
+	ast.True(b.isFailure(&http.Response{StatusCode: http.StatusNotFound}, nil, 0))
+
+	b.params.IsFailure = func(*http.Response, error, time.Duration) bool {
+		return false
+	}
+	ast.False(b.isFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil, 0))
+}
+
+func TestCircuitBreakerHalfOpenMaxRequests(t *testing.T) {
+	ast := assert.New(t)
+
+	b := &circuitBreaker{
+		params: CircuitBreakerParams{
+			FailureThreshold:    1,
+			OpenTimeout:         time.Millisecond,
+			HalfOpenMaxRequests: 2,
+		},
+		circuits: map[string]*circuit{},
+	}
+
+	generation, ok := b.allow("k")
+	ast.True(ok)
+	b.report("k", generation, true)
+	_, ok = b.allow("k")
+	ast.False(ok)
+
+	time.Sleep(2 * time.Millisecond)
+	g1, ok := b.allow("k")
+	ast.True(ok)
+	g2, ok := b.allow("k")
+	ast.True(ok)
+	_, ok = b.allow("k")
+	ast.False(ok)
+
+	b.report("k", g1, false)
+	ast.Equal(CircuitHalfOpen, b.circuits["k"].state)
+	b.report("k", g2, false)
+	ast.Equal(CircuitClosed, b.circuits["k"].state)
+
+	// stale result is ignored
+	b.report("k", g2, true)
+	ast.Equal(0, b.circuits["k"].failures)
+
+	// the released slot can be used by the next trial
+	generation, ok = b.allow("k")
+	ast.True(ok)
+	b.report("k", generation, true)
+	time.Sleep(2 * time.Millisecond)
+	g1, ok = b.allow("k")
+	ast.True(ok)
+	g2, ok = b.allow("k")
+	ast.True(ok)
+	b.release("k", g1)
+	ast.Equal(CircuitHalfOpen, b.circuits["k"].state)
+	_, ok = b.allow("k")
+	ast.True(ok)
+}
+
+func TestCircuitBreakerCanceled(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		requests int32
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 2 {
+			<-r.Context().Done()
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCircuitBreaker(CircuitBreakerParams{
+		FailureThreshold: 1,
+		OpenTimeout:      50 * time.Millisecond,
+	}))
+	_, err := c.Get("/")
+	ast.NoError(err)
+	_, err = c.Get("/")
+	ast.ErrorIs(err, ErrCircuitOpen)
+
+	// the canceled trial neither closes nor reopens the circuit
+	time.Sleep(60 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = c.Get("/", WithContext(ctx))
+	ast.ErrorIs(err, context.Canceled)
+
+	// the next trial is allowed, and it's failed
+	resp, err := c.Get("/")
+	if ast.NoError(err) {
+		ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	}
+	_, err = c.Get("/")
+	ast.ErrorIs(err, ErrCircuitOpen)
+	ast.EqualValues(3, atomic.LoadInt32(&requests))
+}
+
+func TestCircuitState(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("closed", CircuitClosed.String())
+	ast.Equal("open", CircuitOpen.String())
+	ast.Equal("half-open", CircuitHalfOpen.String())
+	ast.Equal("CircuitState(10)", CircuitState(10).String())
+}
@@ -1,14 +1,20 @@
+//go:generate mockgen -package httpclient -destination client_mock.go -source client.go Client
 package httpclient
 
 import (
+	"context"
 	"crypto/tls"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
 )
 
-var _ Client = (*defaultClient)(nil)
+var (
+	_ Client        = (*defaultClient)(nil)
+	_ optionsGetter = (*defaultClient)(nil)
+)
 
 type (
 	Client interface {
@@ -31,6 +37,16 @@ type (
 		newClientHook     func(*resty.Client)
 		beforeRequestHook func(*resty.Request)
 		afterRequestHook  func(*resty.Request, *resty.Response, error)
+		transportWrapper  func(http.RoundTripper) http.RoundTripper
+		retryPolicy       *RetryPolicy
+		contentType       string
+		errorObjectType   reflect.Type
+		errorConverter    ErrorConverter
+		download          downloadOptions
+		multipartParts    []*multipartPart
+		uploadProgress    ProgressFunc
+		stream            streamOptions
+		hedging           *hedging
 	}
 )
 
@@ -41,10 +57,13 @@ func NewClient(addr string, opts ...RequestOption) Client {
 
 	o := newRequestOptions(opts...)
 
-	rawClient := resty.New().SetBaseURL(addr)
+	rawClient := resty.New().SetBaseURL(addr).SetPreRequestHook(setUploadContentLength)
 	if o.newClientHook != nil {
 		o.newClientHook(rawClient)
 	}
+	if o.transportWrapper != nil {
+		rawClient.SetTransport(o.transportWrapper(rawClient.GetClient().Transport))
+	}
 
 	return &defaultClient{
 		Addr:        addr,
@@ -69,6 +88,15 @@ func WithTransport(transport http.RoundTripper) RequestOption {
 	}
 }
 
+// WithContext sets the context of the request, so the cancellation, deadline and values are propagated.
+func WithContext(ctx context.Context) RequestOption {
+	return func(o *requestOptions) {
+		o.linkBeforeRequestHook(func(r *resty.Request) {
+			r.SetContext(ctx)
+		})
+	}
+}
+
 func WithBody(body interface{}) RequestOption {
 	return func(o *requestOptions) {
 		o.linkBeforeRequestHook(func(r *resty.Request) {
@@ -117,6 +145,14 @@ func WithAuthToken(token string) RequestOption {
 	}
 }
 
+func WithBasicAuth(username, password string) RequestOption {
+	return func(o *requestOptions) {
+		o.linkBeforeRequestHook(func(r *resty.Request) {
+			r.SetBasicAuth(username, password)
+		})
+	}
+}
+
 func WithNewClientHook(fn func(*resty.Client)) RequestOption {
 	return func(o *requestOptions) {
 		o.linkNewClientHook(fn)
@@ -162,19 +198,59 @@ func (c *defaultClient) Execute(method, urlPath string, body interface{}, opts .
 	return c.doRequest(method, urlPath, opts...)
 }
 
+func (c *defaultClient) getInitOptions() *requestOptions {
+	return c.initOptions
+}
+
 func (c *defaultClient) doRequest(method, urlPath string, opts ...RequestOption) (*resty.Response, error) {
 	o := c.initOptions.WithOptions(opts...)
 
+	var (
+		r    *resty.Request
+		resp *resty.Response
+		err  error
+	)
+	if o.retryPolicy != nil {
+		r, resp, err = c.executeWithRetry(o, method, urlPath)
+	} else {
+		r, resp, err = c.executeAttempt(o, method, urlPath, nil)
+	}
+	if err == nil {
+		o.decodeErrorObject(r, resp)
+	}
+
+	if o.afterRequestHook != nil {
+		o.afterRequestHook(r, resp, err)
+	}
+	return resp, err
+}
+
+// executeOnce executes one attempt of the request with a new *resty.Request,
+// prepare is called after the beforeRequestHook if it's not nil, and before the body is wrapped for uploading.
+func (c *defaultClient) executeOnce(
+	o *requestOptions, method, urlPath string, prepare func(*resty.Request) error,
+) (*resty.Request, *resty.Response, error) {
 	r := c.client.R()
 	if o.beforeRequestHook != nil {
 		o.beforeRequestHook(r)
 	}
+	if err := o.encodeBody(r); err != nil {
+		return r, nil, err
+	}
+	if prepare != nil {
+		if err := prepare(r); err != nil {
+			return r, nil, err
+		}
+	}
+	if err := o.prepareUploadBody(r); err != nil {
+		return r, nil, err
+	}
 
 	resp, err := r.Execute(method, urlPath)
-	if o.afterRequestHook != nil {
-		o.afterRequestHook(r, resp, err)
+	if b, ok := r.Body.(*uploadBody); ok {
+		_ = b.Close() // stop writing the body if it's not sent
 	}
-	return resp, err
+	return r, resp, err
 }
 
 func newRequestOptions(opts ...RequestOption) *requestOptions {
@@ -193,6 +269,19 @@ func (o *requestOptions) linkNewClientHook(fn func(*resty.Client)) {
 	}
 }
 
+// linkTransportWrapper links the wrapper of transport, the later linked one is the outer one.
+// The transport is wrapped after newClientHook, so it only takes effect for NewClient.
+func (o *requestOptions) linkTransportWrapper(fn func(http.RoundTripper) http.RoundTripper) {
+	if o.transportWrapper == nil {
+		o.transportWrapper = fn
+		return
+	}
+	preWrapper := o.transportWrapper
+	o.transportWrapper = func(rt http.RoundTripper) http.RoundTripper {
+		return fn(preWrapper(rt))
+	}
+}
+
 func (o *requestOptions) linkBeforeRequestHook(fn func(*resty.Request)) {
 	if o.beforeRequestHook == nil {
 		o.beforeRequestHook = fn
@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
@@ -355,3 +357,39 @@ func TestClient(t *testing.T) {
 		}
 	}
 }
+
+func TestWithContext(t *testing.T) {
+	type ctxKey struct{}
+
+	ast := assert.New(t)
+	done := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+	defer close(done)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	c := NewClient(testServer.URL, WithAfterRequestHook(func(r *resty.Request, _ *resty.Response, _ error) {
+		ast.Equal("v", r.Context().Value(ctxKey{}))
+	}))
+	resp, err := c.Get("/", WithContext(ctx))
+	ast.NoError(err)
+	ast.Equal(http.StatusOK, resp.StatusCode())
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.Get("/", WithContext(canceledCtx))
+	ast.ErrorIs(err, context.Canceled)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = NewObjectClientRaw(c).Post("/slow", "body", nil, WithContext(timeoutCtx))
+	ast.ErrorIs(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	_ http.RoundTripper = (*coalescingTransport)(nil)
+	_ context.Context   = (*detachedContext)(nil)
+
+	// DefaultCoalescingHeaders are the headers in the key of coalescing,
+	// so the responses are not shared among the different credentials and representations.
+	DefaultCoalescingHeaders = []string{"Authorization", "Cookie", "Accept", "Accept-Language"}
+)
+
+type (
+	CoalescingParams struct {
+		// Headers are the request headers in the key of coalescing besides the method and url,
+		// default is DefaultCoalescingHeaders.
+		Headers []string
+		// ShouldCoalesce reports whether to coalesce the request, the requests with WithoutCoalescing are never coalesced.
+		// Default is the GET and HEAD requests without body, Range header and Accept of text/event-stream.
+		ShouldCoalesce func(r *http.Request) bool
+	}
+
+	coalescingTransport struct {
+		params CoalescingParams
+		next   http.RoundTripper
+		group  *coalescingGroup
+	}
+
+	// coalescingGroup is shared by the transports with the same option.
+	coalescingGroup struct {
+		mu    sync.Mutex
+		calls map[string]*coalescingCall
+	}
+
+	// coalescingCall is the upstream request shared by the waiters, it's canceled once all of them leave.
+	coalescingCall struct {
+		done    chan struct{}
+		cancel  context.CancelFunc
+		waiters int
+		resp    *coalescedResponse
+		err     error
+	}
+
+	coalescedResponse struct {
+		resp *http.Response
+		body []byte
+	}
+
+	noCoalescingCtxKey struct{}
+
+	// detachedContext keeps the values but not the cancellation of the parent,
+	// so the shared request is not canceled by one of the callers.
+	detachedContext struct {
+		parent context.Context
+	}
+)
+
+// WithCoalescing collapses the concurrent identical requests into one upstream request,
+// the response body is read into memory and each caller gets a copy of it, so it's not for the streaming responses,
+// use WithoutCoalescing for them, the ones of StreamClient and DownloadClient are not coalesced.
+// Each caller waits with its own context, and the upstream request is canceled once all of them leave.
+// It only takes effect for NewClient.
+func WithCoalescing(params CoalescingParams) RequestOption {
+	if params.Headers == nil {
+		params.Headers = DefaultCoalescingHeaders
+	}
+	if params.ShouldCoalesce == nil {
+		params.ShouldCoalesce = shouldCoalesce
+	}
+
+	group := &coalescingGroup{calls: map[string]*coalescingCall{}}
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &coalescingTransport{params: params, next: rt, group: group}
+		})
+	}
+}
+
+// WithoutCoalescing sends the request without coalescing, such as the request with SetDoNotParseResponse.
+func WithoutCoalescing() RequestOption {
+	return WithBeforeRequestHook(skipCoalescing)
+}
+
+func (t *coalescingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if skip, _ := r.Context().Value(noCoalescingCtxKey{}).(bool); skip || !t.params.ShouldCoalesce(r) {
+		return t.next.RoundTrip(r)
+	}
+
+	key := t.key(r)
+	c := t.group.join(r.Context(), key, func(ctx context.Context) (*http.Response, error) {
+		return t.next.RoundTrip(r.Clone(ctx))
+	})
+
+	select {
+	case <-r.Context().Done():
+		t.group.leave(key, c)
+		return nil, r.Context().Err()
+	case <-c.done:
+		if c.err != nil {
+			return nil, c.err
+		}
+		return c.resp.copy(r), nil
+	}
+}
+
+func (t *coalescingTransport) key(r *http.Request) string {
+	var sb strings.Builder
+	sb.WriteString(r.Method)
+	sb.WriteByte(' ')
+	sb.WriteString(r.URL.String())
+	for _, h := range t.params.Headers {
+		sb.WriteByte('\n')
+		sb.WriteString(h)
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return sb.String()
+}
+
+// join joins the call of key, which is started by roundTrip with the detached context of parent if it's not found.
+func (g *coalescingGroup) join(
+	parent context.Context, key string, roundTrip func(ctx context.Context) (*http.Response, error),
+) *coalescingCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(&detachedContext{parent: parent})
+		c = &coalescingCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.do(ctx, key, c, roundTrip)
+	}
+	c.waiters++
+	return c
+}
+
+// leave leaves the call before it's done, and cancels it if there is no other waiter.
+func (g *coalescingGroup) leave(key string, c *coalescingCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters == 0 && g.calls[key] == c {
+		delete(g.calls, key)
+		c.cancel()
+	}
+}
+
+func (g *coalescingGroup) do(
+	ctx context.Context, key string, c *coalescingCall, roundTrip func(ctx context.Context) (*http.Response, error),
+) {
+	defer c.cancel()
+
+	resp, err := roundTrip(ctx)
+	if err == nil {
+		var body []byte
+		body, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err == nil {
+			c.resp = &coalescedResponse{resp: resp, body: body}
+		}
+	}
+	c.err = err
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// copy returns the copy of response for the request, the body is not shared.
+func (c *coalescedResponse) copy(r *http.Request) *http.Response {
+	resp := *c.resp
+	resp.Header = c.resp.Header.Clone()
+	resp.Trailer = c.resp.Trailer.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(c.body))
+	resp.ContentLength = int64(len(c.body))
+	resp.Request = r
+	return &resp
+}
+
+func (*detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (*detachedContext) Done() <-chan struct{}       { return nil }
+func (*detachedContext) Err() error                  { return nil }
+
+func (c *detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+func skipCoalescing(r *resty.Request) {
+	r.SetContext(context.WithValue(r.Context(), noCoalescingCtxKey{}, true))
+}
+
+func shouldCoalesce(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		return false
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return false
+	}
+	return r.Header.Get("Range") == ""
+}
@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCoalescing(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls   int32
+		release = make(chan struct{})
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		w.Header().Set("X-Path", r.URL.Path)
+		_, _ = io.WriteString(w, r.URL.Path+" "+r.Header.Get("Authorization"))
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCoalescing(CoalescingParams{}))
+
+	const n = 20
+	var (
+		wg     sync.WaitGroup
+		bodies = make([]string, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.Get("/slow")
+			if ast.NoError(err) {
+				ast.Equal("/slow", resp.Header().Get("X-Path"))
+				bodies[i] = string(resp.Body())
+			}
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	ast.EqualValues(1, atomic.LoadInt32(&calls))
+	for _, body := range bodies {
+		ast.Equal("/slow ", body)
+	}
+
+	// the requests with different keys are not coalesced
+	atomic.StoreInt32(&calls, 0)
+	for _, token := range []string{"t1", "t2"} {
+		resp, err := c.Get("/slow", WithAuthToken(token))
+		ast.NoError(err)
+		ast.Equal("/slow Bearer "+token, string(resp.Body()))
+	}
+	for _, fn := range []func() error{
+		func() error { _, err := c.Post("/slow", "body"); return err },
+		func() error { _, err := c.Get("/slow", WithHeader("Range", "bytes=0-")); return err },
+	} {
+		ast.NoError(fn())
+	}
+	ast.EqualValues(4, atomic.LoadInt32(&calls))
+}
+
+func TestWithCoalescingContext(t *testing.T) {
+	ast := assert.New(t)
+
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCoalescing(CoalescingParams{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Get("/", WithContext(ctx))
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// the others are not canceled by the first caller
+	respc := make(chan string, 1)
+	go func() {
+		resp, err := c.Get("/")
+		ast.NoError(err)
+		respc <- string(resp.Body())
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	ast.ErrorIs(<-errc, context.Canceled)
+	close(release)
+	ast.Equal("ok", <-respc)
+}
+
+func TestWithCoalescingCancel(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls    int32
+		canceled = make(chan struct{}, 1)
+		release  = make(chan struct{})
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+			return
+		case <-release:
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	c := NewClient(testServer.URL, WithCoalescing(CoalescingParams{}))
+
+	// the upstream request is canceled once all the callers leave
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Get("/", WithContext(ctx))
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	ast.ErrorIs(<-errc, context.Canceled)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		ast.Fail("the upstream request is not canceled")
+	}
+
+	// the deadline of the first caller is not applied to the others
+	respc := make(chan string, 1)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	go func() {
+		_, err := c.Get("/", WithContext(ctx))
+		errc <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	go func() {
+		resp, err := c.Get("/")
+		ast.NoError(err)
+		respc <- string(resp.Body())
+	}()
+	ast.Error(<-errc)
+	time.Sleep(20 * time.Millisecond)
+	release <- struct{}{}
+	ast.Equal("ok", <-respc)
+	ast.EqualValues(2, atomic.LoadInt32(&calls))
+}
+
+func TestWithCoalescingStream(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls   int32
+		release atomic.Value
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release.Load().(chan struct{})
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithCoalescing(CoalescingParams{}))
+	for _, opts := range [][]RequestOption{
+		{WithHeader("Accept", "text/event-stream")},
+		{WithoutCoalescing()},
+	} {
+		atomic.StoreInt32(&calls, 0)
+		ch := make(chan struct{})
+		release.Store(ch)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Get("/", opts...)
+				ast.NoError(err)
+			}()
+		}
+		ast.Eventually(func() bool {
+			return atomic.LoadInt32(&calls) == 2
+		}, time.Second, 5*time.Millisecond)
+		close(ch)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,256 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+	ContentTypeYAML = "application/yaml"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+	ContentTypeText = "text/plain"
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+
+	codecs = struct {
+		sync.RWMutex
+		decoders map[string]Decoder
+		encoders map[string]Encoder
+	}{
+		decoders: map[string]Decoder{},
+		encoders: map[string]Encoder{},
+	}
+)
+
+type (
+	// Decoder decodes the response body into v.
+	Decoder interface {
+		Decode(data []byte, v interface{}) error
+	}
+
+	// Encoder encodes v into the request body.
+	Encoder interface {
+		Encode(v interface{}) ([]byte, error)
+	}
+
+	DecoderFunc func(data []byte, v interface{}) error
+	EncoderFunc func(v interface{}) ([]byte, error)
+)
+
+func init() {
+	for _, mediaType := range []string{ContentTypeJSON, "text/json"} {
+		RegisterDecoder(mediaType, DecoderFunc(json.Unmarshal))
+		RegisterEncoder(mediaType, EncoderFunc(json.Marshal))
+	}
+	for _, mediaType := range []string{ContentTypeXML, "text/xml"} {
+		RegisterDecoder(mediaType, DecoderFunc(xml.Unmarshal))
+		RegisterEncoder(mediaType, EncoderFunc(xml.Marshal))
+	}
+	for _, mediaType := range []string{ContentTypeYAML, "application/x-yaml", "text/yaml"} {
+		RegisterDecoder(mediaType, DecoderFunc(yaml.Unmarshal))
+		RegisterEncoder(mediaType, EncoderFunc(yaml.Marshal))
+	}
+	RegisterDecoder(ContentTypeForm, DecoderFunc(decodeForm))
+	RegisterEncoder(ContentTypeForm, EncoderFunc(encodeForm))
+	RegisterEncoder(ContentTypeText, EncoderFunc(encodeText))
+}
+
+// RegisterDecoder registers the decoder of the media type, the registered one is replaced.
+func RegisterDecoder(mediaType string, d Decoder) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.decoders[strings.ToLower(mediaType)] = d
+}
+
+// RegisterEncoder registers the encoder of the media type, the registered one is replaced.
+func RegisterEncoder(mediaType string, e Encoder) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.encoders[strings.ToLower(mediaType)] = e
+}
+
+// GetDecoder returns the decoder of the content type.
+// The structured syntax suffix is used if the media type is not registered, such as application/problem+json.
+func GetDecoder(contentType string) (Decoder, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	d, ok := codecs.decoders[lookupMediaType(contentType, func(mediaType string) bool {
+		_, ok := codecs.decoders[mediaType]
+		return ok
+	})]
+	return d, ok
+}
+
+// GetEncoder returns the encoder of the content type.
+// The structured syntax suffix is used if the media type is not registered, such as application/merge-patch+json.
+func GetEncoder(contentType string) (Encoder, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	e, ok := codecs.encoders[lookupMediaType(contentType, func(mediaType string) bool {
+		_, ok := codecs.encoders[mediaType]
+		return ok
+	})]
+	return e, ok
+}
+
+// WithContentType sets the Content-Type header, and encodes the body by the registered encoder.
+// The body of string, []byte and io.Reader is sent as is.
+func WithContentType(contentType string) RequestOption {
+	return func(o *requestOptions) {
+		o.contentType = contentType
+	}
+}
+
+func (f DecoderFunc) Decode(data []byte, v interface{}) error {
+	if f == nil {
+		return nil
+	}
+	return f(data, v)
+}
+
+func (f EncoderFunc) Encode(v interface{}) ([]byte, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return f(v)
+}
+
+// encodeBody encodes the body of r by the content type.
+func (o *requestOptions) encodeBody(r *resty.Request) error {
+	if o.contentType == "" {
+		return nil
+	}
+	r.SetHeader("Content-Type", o.contentType)
+
+	switch r.Body.(type) {
+	case nil, string, []byte, io.Reader:
+		return nil
+	}
+
+	e, ok := GetEncoder(o.contentType)
+	if !ok {
+		return errors.Wrap(ErrUnsupportedContentType, o.contentType)
+	}
+	bs, err := e.Encode(r.Body)
+	if err != nil {
+		return err
+	}
+	r.SetBody(bs)
+	return nil
+}
+
+// decodeResponse decodes the response body into v by the Content-Type.
+// The *string and *[]byte are filled with the raw body unless the Content-Type is json, and so is the io.Writer always.
+// The json is used if there is no decoder for the Content-Type, such as the text/plain sniffed by the server.
+func decodeResponse(resp *resty.Response, v interface{}) error {
+	return decodeBody(resp.Header().Get("Content-Type"), resp.Body(), v)
+}
+
+func decodeBody(contentType string, body []byte, v interface{}) error {
+	if w, ok := v.(io.Writer); ok {
+		_, err := w.Write(body)
+		return err
+	}
+	if !isJSONContentType(contentType) {
+		switch v := v.(type) {
+		case *string:
+			*v = string(body)
+			return nil
+		case *[]byte:
+			*v = append((*v)[:0], body...)
+			return nil
+		}
+	}
+
+	d, ok := GetDecoder(contentType)
+	if !ok {
+		return json.Unmarshal(body, v)
+	}
+	return d.Decode(body, v)
+}
+
+// isJSONContentType reports whether the content type is json, such as application/problem+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType == ContentTypeJSON || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// lookupMediaType returns the media type of the content type which is registered.
+func lookupMediaType(contentType string, registered func(mediaType string) bool) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if registered(mediaType) {
+		return mediaType
+	}
+	if index := strings.LastIndex(mediaType, "+"); index >= 0 {
+		suffixMediaType := "application/" + mediaType[index+1:]
+		if registered(suffixMediaType) {
+			return suffixMediaType
+		}
+	}
+	return mediaType
+}
+
+func decodeForm(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case *url.Values:
+		*v = values
+	case *map[string][]string:
+		*v = values
+	case *map[string]string:
+		*v = make(map[string]string, len(values))
+		for k := range values {
+			(*v)[k] = values.Get(k)
+		}
+	default:
+		return fmt.Errorf("unsupported type %T to decode form", v)
+	}
+	return nil
+}
+
+func encodeForm(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case url.Values:
+		return []byte(v.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(v).Encode()), nil
+	case map[string]string:
+		values := make(url.Values, len(v))
+		for k, val := range v {
+			values.Set(k, val)
+		}
+		return []byte(values.Encode()), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T to encode form", v)
+}
+
+func encodeText(v interface{}) ([]byte, error) {
+	if s, ok := v.(fmt.Stringer); ok {
+		return []byte(s.String()), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T to encode text", v)
+}
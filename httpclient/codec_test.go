@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type codecTestObject struct {
+	XMLName xml.Name `json:"-" xml:"object" yaml:"-"`
+	Name    string   `json:"name" xml:"name" yaml:"name"`
+	Value   int      `json:"value" xml:"value" yaml:"value"`
+}
+
+func TestObjectClientDecode(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType := r.URL.Query().Get("contentType"); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		_, _ = io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+	defer testServer.Close()
+
+	c := NewObjectClient(testServer.URL)
+	get := func(contentType, body string, v interface{}) error {
+		return c.Get("/", v, WithQueryParams(map[string]string{"contentType": contentType, "body": body}))
+	}
+	expected := codecTestObject{Name: "n", Value: 1}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "default", contentType: "", body: `{"name":"n","value":1}`},
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"name":"n","value":1}`},
+		{name: "json suffix", contentType: "application/problem+json", body: `{"name":"n","value":1}`},
+		{name: "text", contentType: "text/plain; charset=utf-8", body: `{"name":"n","value":1}`},
+		{name: "xml", contentType: "application/xml", body: `<object><name>n</name><value>1</value></object>`},
+		{name: "text xml", contentType: "text/xml", body: `<object><name>n</name><value>1</value></object>`},
+		{name: "yaml", contentType: "application/yaml", body: "name: n\nvalue: 1\n"},
+		{name: "x-yaml", contentType: "application/x-yaml", body: "name: n\nvalue: 1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var obj codecTestObject
+			err := get(test.contentType, test.body, &obj)
+			assert.NoError(t, err)
+			obj.XMLName = xml.Name{}
+			assert.Equal(t, expected, obj)
+		})
+	}
+
+	var form url.Values
+	ast.NoError(get(ContentTypeForm, "a=1&b=2&b=3", &form))
+	ast.Equal(url.Values{"a": {"1"}, "b": {"2", "3"}}, form)
+
+	var formMap map[string]string
+	ast.NoError(get(ContentTypeForm, "a=1&b=2&b=3", &formMap))
+	ast.Equal(map[string]string{"a": "1", "b": "2"}, formMap)
+
+	var s string
+	ast.NoError(get(ContentTypeText, "text", &s))
+	ast.Equal("text", s)
+
+	var bs []byte
+	ast.NoError(get("application/octet-stream", "raw", &bs))
+	ast.Equal([]byte("raw"), bs)
+
+	// the json string is decoded
+	ast.NoError(get(ContentTypeJSON, `"abc"`, &s))
+	ast.Equal("abc", s)
+	ast.NoError(get("application/problem+json", `"YWJj"`, &bs))
+	ast.Equal([]byte("abc"), bs)
+
+	var buff bytes.Buffer
+	ast.NoError(get(ContentTypeJSON, "writer", &buff))
+	ast.Equal("writer", buff.String())
+
+	var obj codecTestObject
+	err := get(ContentTypeXML, "invalid", &obj)
+	ast.True(IsResponseError(err))
+
+	err = get(ContentTypeJSON, "invalid", &obj)
+	ast.True(IsResponseError(err))
+}
+
+func TestWithContentType(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL)
+	obj := codecTestObject{Name: "foo", Value: 1}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        interface{}
+		expected    string
+	}{
+		{name: "json", contentType: ContentTypeJSON, body: obj, expected: `{"name":"foo","value":1}`},
+		{name: "xml", contentType: ContentTypeXML, body: obj, expected: `<object><name>foo</name><value>1</value></object>`},
+		{name: "yaml", contentType: ContentTypeYAML, body: obj, expected: "name: foo\nvalue: 1\n"},
+		{name: "form", contentType: ContentTypeForm, body: map[string]string{"b": "2", "a": "1"}, expected: "a=1&b=2"},
+		{name: "form values", contentType: ContentTypeForm, body: url.Values{"a": {"1", "2"}}, expected: "a=1&a=2"},
+		{name: "raw", contentType: ContentTypeYAML, body: "raw", expected: "raw"},
+		{name: "reader", contentType: ContentTypeYAML, body: strings.NewReader("reader"), expected: "reader"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := c.Post("/", test.body, WithContentType(test.contentType))
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.contentType, resp.Header().Get("Content-Type"))
+				assert.Equal(t, test.expected, string(resp.Body()))
+			}
+		})
+	}
+
+	_, err := c.Post("/", obj, WithContentType("application/unknown"))
+	ast.ErrorIs(err, ErrUnsupportedContentType)
+
+	_, err = c.Post("/", obj, WithContentType(ContentTypeForm))
+	ast.Error(err)
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	ast := assert.New(t)
+
+	RegisterDecoder("application/x-test", DecoderFunc(func(data []byte, v interface{}) error {
+		*v.(*codecTestObject) = codecTestObject{Name: string(data)}
+		return nil
+	}))
+	RegisterEncoder("application/x-test", EncoderFunc(func(v interface{}) ([]byte, error) {
+		return []byte(v.(codecTestObject).Name), nil
+	}))
+
+	d, ok := GetDecoder("application/x-test; charset=utf-8")
+	if ast.True(ok) {
+		var obj codecTestObject
+		ast.NoError(d.Decode([]byte("n"), &obj))
+		ast.Equal("n", obj.Name)
+	}
+	e, ok := GetEncoder("Application/X-Test")
+	if ast.True(ok) {
+		bs, err := e.Encode(codecTestObject{Name: "n"})
+		ast.NoError(err)
+		ast.Equal([]byte("n"), bs)
+	}
+
+	_, ok = GetDecoder("application/vnd.test+yaml")
+	ast.True(ok)
+	_, ok = GetDecoder("application/unknown")
+	ast.False(ok)
+
+	ast.NoError(DecoderFunc(nil).Decode(nil, nil))
+	bs, err := EncoderFunc(nil).Encode(nil)
+	ast.NoError(err)
+	ast.Nil(bs)
+
+	resp := &resty.Response{RawResponse: &http.Response{Header: http.Header{"Content-Type": {"application/x-test"}}}}
+	resp.SetBody([]byte("body"))
+	var obj codecTestObject
+	ast.NoError(decodeResponse(resp, &obj))
+	ast.Equal("body", obj.Name)
+}
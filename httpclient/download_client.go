@@ -0,0 +1,382 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultDownloadMaxResumes = 3
+	// DownloadValidatorFileSuffix is the suffix of the file which keeps the validator of the partial file,
+	// see DownloadClient.DownloadFile.
+	DownloadValidatorFileSuffix = ".validator"
+
+	downloadBufferSize   = 32 * 1024
+	downloadErrorMaxSize = 64 * 1024
+)
+
+var (
+	_ DownloadClient = (*defaultDownloadClient)(nil)
+
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrDownloadChanged  = errors.New("content changed while resuming")
+)
+
+type (
+	// DownloadClient downloads the response body in streaming.
+	DownloadClient interface {
+		// Download writes the response body to w, and returns the number of bytes written.
+		Download(urlPath string, w io.Writer, opts ...RequestOption) (int64, error)
+		// DownloadFile writes the response body to the file, the existing file is resumed by HTTP Range request
+		// with If-Range, so it's downloaded again once the content is changed.
+		// The validator (ETag or Last-Modified) of the partial file is kept in the file with
+		// DownloadValidatorFileSuffix until it's completed, the existing file without it is downloaded again.
+		DownloadFile(urlPath, filePath string, opts ...RequestOption) (int64, error)
+	}
+
+	// ProgressFunc reports the progress of transfer, the total is -1 if it's unknown.
+	ProgressFunc func(transferred, total int64)
+
+	defaultDownloadClient struct {
+		client Client
+	}
+
+	downloadOptions struct {
+		progress   ProgressFunc
+		newHash    func() hash.Hash
+		checksum   string
+		maxResumes int
+	}
+
+	downloadTask struct {
+		client  Client
+		urlPath string
+		opts    []RequestOption
+		o       *downloadOptions
+		hash    hash.Hash
+		w       io.Writer
+		written int64 // the total bytes written including the existing ones
+		copied  int64 // the bytes written in this run
+		total   int64
+		// validator is the strong ETag or Last-Modified of the content, it's sent by If-Range while resuming.
+		validator string
+		// restart is called if the server responds the whole content while resuming,
+		// the bytes written are discarded from the response body if it's nil.
+		restart func() error
+		// onValidator is called with the validator of the response before writing, it can be nil.
+		onValidator func(validator string) error
+	}
+
+	// downloadReadError is the error of reading the response body, the download can be resumed.
+	downloadReadError struct {
+		error
+	}
+)
+
+func NewDownloadClient(addr string, opts ...RequestOption) DownloadClient {
+	return NewDownloadClientRaw(NewClient(addr, opts...))
+}
+
+func NewDownloadClientRaw(cli Client) DownloadClient {
+	return &defaultDownloadClient{
+		client: cli,
+	}
+}
+
+// WithDownloadProgress reports the progress of downloading.
+func WithDownloadProgress(fn ProgressFunc) RequestOption {
+	return func(o *requestOptions) {
+		o.download.progress = fn
+	}
+}
+
+// WithDownloadChecksum verifies the downloaded content by the hash created by newHash and the expected hex checksum,
+// ErrChecksumMismatch is returned if they are mismatched. For examples:
+//
+//	c.Download("/file", w, WithDownloadChecksum(sha256.New, checksum))
+func WithDownloadChecksum(newHash func() hash.Hash, checksum string) RequestOption {
+	return func(o *requestOptions) {
+		o.download.newHash = newHash
+		o.download.checksum = checksum
+	}
+}
+
+// WithDownloadMaxResumes sets the max number of resuming by HTTP Range request after the read is interrupted,
+// default is DefaultDownloadMaxResumes.
+func WithDownloadMaxResumes(n int) RequestOption {
+	return func(o *requestOptions) {
+		o.download.maxResumes = n
+	}
+}
+
+func (c *defaultDownloadClient) Download(urlPath string, w io.Writer, opts ...RequestOption) (int64, error) {
+	t := c.newTask(urlPath, opts)
+	t.w = w
+	return t.run()
+}
+
+func (c *defaultDownloadClient) DownloadFile(urlPath, filePath string, opts ...RequestOption) (int64, error) {
+	t := c.newTask(urlPath, opts)
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0o644) //nolint:gomnd
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	t.w = f
+	t.restart = func() error {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		_, err := f.Seek(0, io.SeekStart)
+		return err
+	}
+	validatorPath := filePath + DownloadValidatorFileSuffix
+	t.onValidator = func(validator string) error {
+		if validator == "" {
+			return removeIfExists(validatorPath)
+		}
+		return os.WriteFile(validatorPath, []byte(validator), 0o644) //nolint:gomnd,gosec
+	}
+
+	if validator, err := os.ReadFile(validatorPath); err == nil && len(validator) > 0 {
+		t.validator = string(validator)
+		if t.hash != nil {
+			if _, err = io.Copy(t.hash, f); err != nil {
+				return 0, err
+			}
+		}
+		if t.written, err = f.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	} else if err = t.restart(); err != nil { // the partial file can not be verified
+		return 0, err
+	}
+
+	n, err := t.run()
+	if err != nil {
+		return n, err
+	}
+	if err = f.Sync(); err != nil {
+		return n, err
+	}
+	return n, removeIfExists(validatorPath)
+}
+
+func (c *defaultDownloadClient) newTask(urlPath string, opts []RequestOption) *downloadTask {
+	o := requestOptionsOf(c.client, opts...).download
+	if o.maxResumes == 0 {
+		o.maxResumes = DefaultDownloadMaxResumes
+	}
+
+	t := &downloadTask{
+		client:  c.client,
+		urlPath: urlPath,
+		opts:    opts,
+		o:       &o,
+		total:   -1,
+	}
+	if o.newHash != nil {
+		t.hash = o.newHash()
+	}
+	return t
+}
+
+// run downloads and resumes until completed, and returns the number of bytes written in this run.
+func (t *downloadTask) run() (int64, error) {
+	for resumes := 0; ; resumes++ {
+		err := t.fetch()
+		var readErr *downloadReadError
+		if err == nil {
+			break
+		}
+		if !errors.As(err, &readErr) || resumes >= t.o.maxResumes ||
+			errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return t.copied, err
+		}
+	}
+
+	if t.hash != nil {
+		if checksum := hex.EncodeToString(t.hash.Sum(nil)); !strings.EqualFold(checksum, t.o.checksum) {
+			return t.copied, errors.Wrapf(ErrChecksumMismatch, "expected %s, got %s", t.o.checksum, checksum)
+		}
+	}
+	return t.copied, nil
+}
+
+// fetch requests the content from the written offset, and writes it.
+func (t *downloadTask) fetch() error {
+	offset, validator := t.written, t.validator
+	opts := make([]RequestOption, 0, len(t.opts)+1)
+	opts = append(opts, t.opts...)
+	opts = append(opts, WithBeforeRequestHook(func(r *resty.Request) {
+		r.SetDoNotParseResponse(true)
+		skipCoalescing(r)
+		if offset > 0 {
+			r.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+			if validator != "" {
+				r.SetHeader("If-Range", validator)
+			}
+		}
+	}))
+
+	resp, err := t.client.Get(t.urlPath, opts...)
+	if err != nil {
+		return err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+
+	var discard int64
+	switch {
+	case resp.StatusCode() == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header().Get("Content-Range"))
+		if !ok || start != offset {
+			return NewResponseError(resp, errors.Errorf("unexpected Content-Range %q", resp.Header().Get("Content-Range")))
+		}
+		t.total = total
+	case resp.StatusCode() == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		if _, total, ok := parseContentRange(resp.Header().Get("Content-Range")); ok && total == offset {
+			t.total = total
+			return nil
+		}
+		return t.responseError(resp, body)
+	case resp.IsSuccess():
+		t.total = resp.RawResponse.ContentLength
+		if offset > 0 {
+			if t.restart != nil {
+				if err = t.restart(); err != nil {
+					return err
+				}
+				if t.hash != nil {
+					t.hash.Reset()
+				}
+				t.written, t.copied = 0, 0
+			} else {
+				// the server ignores the range if the validator is not changed
+				if validator != "" && responseValidator(resp) != validator {
+					return NewResponseError(resp, ErrDownloadChanged)
+				}
+				discard = offset
+			}
+		}
+		t.validator = responseValidator(resp)
+		if t.onValidator != nil {
+			if err = t.onValidator(t.validator); err != nil {
+				return err
+			}
+		}
+	default:
+		return t.responseError(resp, body)
+	}
+
+	if discard > 0 {
+		if _, err = io.CopyN(io.Discard, body, discard); err != nil {
+			return &downloadReadError{error: err}
+		}
+	}
+	return t.copy(body)
+}
+
+func (t *downloadTask) copy(body io.Reader) error {
+	w := t.w
+	if t.hash != nil {
+		w = io.MultiWriter(t.w, t.hash)
+	}
+
+	buf := make([]byte, downloadBufferSize)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			t.written += int64(n)
+			t.copied += int64(n)
+			if t.o.progress != nil {
+				t.o.progress(t.written, t.total)
+			}
+		}
+		if err == io.EOF {
+			if t.total >= 0 && t.written < t.total {
+				return &downloadReadError{error: io.ErrUnexpectedEOF}
+			}
+			return nil
+		}
+		if err != nil {
+			return &downloadReadError{error: err}
+		}
+	}
+}
+
+func (*downloadTask) responseError(resp *resty.Response, body io.Reader) error {
+	bs, _ := io.ReadAll(io.LimitReader(body, downloadErrorMaxSize))
+	resp.SetBody(bs)
+	return NewResponseError(resp, nil)
+}
+
+func (e *downloadReadError) Unwrap() error { return e.error }
+
+// responseValidator returns the strong ETag or Last-Modified of resp which can be used by If-Range.
+func responseValidator(resp *resty.Response) string {
+	if etag := resp.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header().Get("Last-Modified")
+}
+
+func removeIfExists(name string) error {
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// parseContentRange parses the Content-Range header, such as "bytes 100-199/200" and "bytes */200",
+// the total is -1 if it's unknown.
+func parseContentRange(contentRange string) (start, total int64, ok bool) {
+	contentRange = strings.TrimSpace(contentRange)
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, 0, false
+	}
+	contentRange = strings.TrimPrefix(contentRange, "bytes ")
+
+	index := strings.Index(contentRange, "/")
+	if index < 0 {
+		return 0, 0, false
+	}
+	rangePart, totalPart := contentRange[:index], contentRange[index+1:]
+
+	total = -1
+	if totalPart != "*" {
+		v, err := strconv.ParseInt(totalPart, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		total = v
+	}
+
+	if rangePart == "*" {
+		return 0, total, true
+	}
+	index = strings.Index(rangePart, "-")
+	if index < 0 {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(rangePart[:index], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
@@ -0,0 +1,260 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadClient(t *testing.T) {
+	var (
+		ast        = assert.New(t)
+		content    = bytes.Repeat([]byte("0123456789"), 10000)
+		sum        = sha256.Sum256(content)
+		checksum   = hex.EncodeToString(sum[:])
+		interrupts int32
+		ranges     = make(chan string, 10)
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges <- r.Header.Get("Range")
+		switch r.URL.Path {
+		case "/file":
+			if atomic.AddInt32(&interrupts, -1) >= 0 {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+		case "/no-range":
+			_, _ = w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "not found")
+		}
+	}))
+	defer testServer.Close()
+
+	drainRanges := func() []string {
+		var rs []string
+		for len(ranges) > 0 {
+			rs = append(rs, <-ranges)
+		}
+		return rs
+	}
+
+	var progressed int64
+	c := NewDownloadClient(testServer.URL, WithDownloadProgress(func(transferred, total int64) {
+		atomic.StoreInt64(&progressed, transferred)
+		ast.Equal(int64(len(content)), total)
+	}))
+
+	// download to writer
+	var buff bytes.Buffer
+	n, err := c.Download("/file", &buff, WithDownloadChecksum(sha256.New, checksum))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)), n)
+	ast.Equal(content, buff.Bytes())
+	ast.Equal(int64(len(content)), atomic.LoadInt64(&progressed))
+	ast.Equal([]string{""}, drainRanges())
+
+	// checksum mismatch
+	buff.Reset()
+	_, err = c.Download("/file", &buff, WithDownloadChecksum(sha256.New, "invalid"))
+	ast.ErrorIs(err, ErrChecksumMismatch)
+	drainRanges()
+
+	// resume after interruption
+	buff.Reset()
+	atomic.StoreInt32(&interrupts, 1)
+	n, err = c.Download("/file", &buff, WithDownloadChecksum(sha256.New, checksum))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)), n)
+	ast.Equal(content, buff.Bytes())
+	ast.Equal([]string{"", "bytes=50000-"}, drainRanges())
+
+	// no more resumes
+	buff.Reset()
+	atomic.StoreInt32(&interrupts, 2)
+	_, err = c.Download("/file", &buff, WithDownloadMaxResumes(-1))
+	ast.Error(err)
+	atomic.StoreInt32(&interrupts, 0)
+	drainRanges()
+
+	// not found
+	_, err = c.Download("/not-found", &buff)
+	if e, ok := AsResponseError(err); ast.True(ok) {
+		ast.True(e.IsStatusCode(http.StatusNotFound))
+		ast.Equal("not found", string(e.GetResponse().Body()))
+	}
+	drainRanges()
+}
+
+func TestDownloadClientDownloadFile(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		content  = bytes.Repeat([]byte("0123456789"), 1000)
+		sum      = sha256.Sum256(content)
+		checksum = hex.EncodeToString(sum[:])
+		ranges   = make(chan string, 10)
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges <- strings.TrimSpace(r.Header.Get("Range") + " " + r.Header.Get("If-Range"))
+		if r.URL.Path == "/no-range" {
+			_, _ = w.Write(content)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	c := NewDownloadClientRaw(NewClient(testServer.URL))
+	filePath := filepath.Join(t.TempDir(), "file")
+	validatorPath := filePath + DownloadValidatorFileSuffix
+
+	// resume the existing file
+	ast.NoError(os.WriteFile(filePath, content[:3000], 0o600))
+	ast.NoError(os.WriteFile(validatorPath, []byte(`"v1"`), 0o600))
+	n, err := c.DownloadFile("/file", filePath, WithDownloadChecksum(sha256.New, checksum))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)-3000), n)
+	ast.Equal(`bytes=3000- "v1"`, <-ranges)
+	bs, _ := os.ReadFile(filePath)
+	ast.Equal(content, bs)
+	ast.NoFileExists(validatorPath)
+
+	// the completed file is downloaded again without the validator
+	n, err = c.DownloadFile("/file", filePath, WithDownloadChecksum(sha256.New, checksum),
+		WithDownloadProgress(func(int64, int64) {
+			ast.FileExists(validatorPath)
+		}))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)), n)
+	ast.Equal("", <-ranges)
+	ast.NoFileExists(validatorPath)
+
+	// the content is changed
+	ast.NoError(os.WriteFile(filePath, []byte(strings.Repeat("x", 3000)), 0o600))
+	ast.NoError(os.WriteFile(validatorPath, []byte(`"v0"`), 0o600))
+	n, err = c.DownloadFile("/file", filePath, WithDownloadChecksum(sha256.New, checksum))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)), n)
+	ast.Equal(`bytes=3000- "v0"`, <-ranges)
+	bs, _ = os.ReadFile(filePath)
+	ast.Equal(content, bs)
+
+	// the server ignores the range
+	ast.NoError(os.WriteFile(filePath, []byte(strings.Repeat("x", 3000)), 0o600))
+	ast.NoError(os.WriteFile(validatorPath, []byte(`"v1"`), 0o600))
+	n, err = c.DownloadFile("/no-range", filePath, WithDownloadChecksum(sha256.New, checksum))
+	ast.NoError(err)
+	ast.Equal(int64(len(content)), n)
+	ast.Equal(`bytes=3000- "v1"`, <-ranges)
+	bs, _ = os.ReadFile(filePath)
+	ast.Equal(content, bs)
+	ast.NoFileExists(validatorPath)
+
+	// download to writer and discard the written bytes if the server ignores the range
+	var buff bytes.Buffer
+	task := &downloadTask{
+		client:  NewClient(testServer.URL),
+		urlPath: "/no-range",
+		o:       &downloadOptions{maxResumes: 1},
+		w:       &buff,
+		written: 3000,
+		total:   -1,
+	}
+	n, err = task.run()
+	ast.NoError(err)
+	ast.Equal(int64(len(content)-3000), n)
+	ast.Equal(content[3000:], buff.Bytes())
+	<-ranges
+
+	// download to writer and the content is changed
+	task.w, task.written, task.copied, task.validator = &buff, 3000, 0, `"v0"`
+	task.urlPath = "/file"
+	_, err = task.run()
+	ast.ErrorIs(err, ErrDownloadChanged)
+	ast.Equal(`bytes=3000- "v0"`, <-ranges)
+
+	_, err = c.DownloadFile("/file", filepath.Join(t.TempDir(), "not-exists", "file"))
+	ast.Error(err)
+}
+
+func TestDownloadClientOptions(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		content  = bytes.Repeat([]byte("0123456789"), 1000)
+		sum      = sha256.Sum256(content)
+		checksum = hex.EncodeToString(sum[:])
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	// the options of the wrapped client
+	c := NewDownloadClientRaw(NewClient(testServer.URL, WithDownloadChecksum(sha256.New, "invalid")))
+	_, err := c.Download("/file", io.Discard)
+	ast.ErrorIs(err, ErrChecksumMismatch)
+
+	// the hash is created for each download
+	c = NewDownloadClient(testServer.URL, WithDownloadChecksum(sha256.New, checksum))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Download("/file", io.Discard)
+			ast.NoError(err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		contentRange string
+		start        int64
+		total        int64
+		ok           bool
+	}{
+		{contentRange: "bytes 100-199/200", start: 100, total: 200, ok: true},
+		{contentRange: "bytes 100-199/*", start: 100, total: -1, ok: true},
+		{contentRange: "bytes */200", start: 0, total: 200, ok: true},
+		{contentRange: "", ok: false},
+		{contentRange: "items 1-2/3", ok: false},
+		{contentRange: "bytes 100-199", ok: false},
+		{contentRange: "bytes 100-199/x", ok: false},
+		{contentRange: "bytes 100/200", ok: false},
+		{contentRange: "bytes x-199/200", ok: false},
+	}
+	for _, test := range tests {
+		t.Run(test.contentRange, func(t *testing.T) {
+			start, total, ok := parseContentRange(test.contentRange)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.start, start)
+				assert.Equal(t, test.total, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultEndpointsResolveInterval     = 30 * time.Second
+	DefaultEndpointsMaxFails            = 3
+	DefaultEndpointsEjectDuration       = 30 * time.Second
+	DefaultEndpointsHealthCheckInterval = 10 * time.Second
+	DefaultEndpointsHealthCheckTimeout  = 5 * time.Second
+	DefaultEndpointsMaxAttempts         = 2
+
+	// endpointsBaseURL is the base url of the client, the scheme and host are replaced by the endpoint picked.
+	endpointsBaseURL = "http://endpoints"
+)
+
+var (
+	_ EndpointsClient   = (*defaultEndpointsClient)(nil)
+	_ Endpoint          = (*endpoint)(nil)
+	_ http.RoundTripper = (*endpointsTransport)(nil)
+
+	ErrNoEndpoints = errors.New("no endpoints")
+)
+
+type (
+	// EndpointsClient is the Client which balances the requests among the endpoints.
+	EndpointsClient interface {
+		Client
+		// Endpoints returns the current endpoints.
+		Endpoints() []Endpoint
+		// Close stops the resolving and active health checking.
+		Close()
+	}
+
+	// Endpoint is an endpoint of EndpointsClient.
+	Endpoint interface {
+		Addr() string
+		// InFlight returns the number of in-flight requests.
+		InFlight() int64
+		// Healthy reports whether the endpoint is neither ejected by failures nor failed in health checking.
+		Healthy() bool
+	}
+
+	EndpointsParams struct {
+		// Endpoints are the addresses of endpoints, such as "127.0.0.1:8080" and "https://host:8443/prefix".
+		Endpoints []string
+		// Resolver resolves the addresses of endpoints periodically, it replaces Endpoints if it's not nil.
+		Resolver func(ctx context.Context) ([]string, error)
+		// ResolveInterval is the interval of resolving, default is DefaultEndpointsResolveInterval.
+		ResolveInterval time.Duration
+		// Balancer picks the endpoint for each request, default is NewRoundRobinBalancer.
+		Balancer Balancer
+		// MaxFails is the number of consecutive failures to eject the endpoint, default is DefaultEndpointsMaxFails,
+		// and negative means no passive health checking.
+		MaxFails int
+		// EjectDuration is the duration of the ejection, default is DefaultEndpointsEjectDuration.
+		EjectDuration time.Duration
+		// IsFailure reports whether the call is a failure, default is the errors and 5xx responses.
+		IsFailure func(resp *http.Response, err error) bool
+		// HealthCheckPath is the path to check the endpoints actively, the non-2xx responses are unhealthy,
+		// and the 2xx ones restore the ejected endpoints. It's default empty means no active health checking.
+		HealthCheckPath string
+		// HealthCheckInterval is the interval of active health checking, default is DefaultEndpointsHealthCheckInterval.
+		HealthCheckInterval time.Duration
+		// HealthCheckTimeout is the timeout of each health check, default is DefaultEndpointsHealthCheckTimeout.
+		HealthCheckTimeout time.Duration
+		// MaxAttempts is the max number of endpoints tried for the idempotent requests which are failed,
+		// default is DefaultEndpointsMaxAttempts, see IsIdempotentMethod.
+		MaxAttempts int
+	}
+
+	defaultEndpointsClient struct {
+		Client
+		transport *endpointsTransport
+	}
+
+	endpointsTransport struct {
+		params    EndpointsParams
+		transport http.RoundTripper
+
+		mu        sync.RWMutex
+		endpoints []*endpoint
+
+		closeOnce sync.Once
+		done      chan struct{}
+	}
+
+	endpoint struct {
+		inFlight int64
+
+		addr string
+		url  *url.URL
+
+		mu           sync.Mutex
+		fails        int
+		ejectedUntil time.Time
+		checkFailed  bool
+	}
+)
+
+// NewEndpointsClient creates the client which balances the requests among the endpoints,
+// the urlPath of requests is relative to the endpoint picked.
+// The failed endpoints are ejected by passive or active health checking,
+// and the failed idempotent requests are retried on another endpoint.
+// Close it to stop the background goroutines if Resolver or HealthCheckPath is set.
+func NewEndpointsClient(params EndpointsParams, opts ...RequestOption) EndpointsClient { //nolint:gocritic
+	if params.ResolveInterval <= 0 {
+		params.ResolveInterval = DefaultEndpointsResolveInterval
+	}
+	if params.Balancer == nil {
+		params.Balancer = NewRoundRobinBalancer()
+	}
+	if params.MaxFails == 0 {
+		params.MaxFails = DefaultEndpointsMaxFails
+	}
+	if params.EjectDuration <= 0 {
+		params.EjectDuration = DefaultEndpointsEjectDuration
+	}
+	if params.IsFailure == nil {
+		params.IsFailure = isEndpointFailure
+	}
+	if params.HealthCheckInterval <= 0 {
+		params.HealthCheckInterval = DefaultEndpointsHealthCheckInterval
+	}
+	if params.HealthCheckTimeout <= 0 {
+		params.HealthCheckTimeout = DefaultEndpointsHealthCheckTimeout
+	}
+	if params.MaxAttempts <= 0 {
+		params.MaxAttempts = DefaultEndpointsMaxAttempts
+	}
+
+	t := &endpointsTransport{
+		params: params,
+		done:   make(chan struct{}),
+	}
+	t.setEndpoints(params.Endpoints)
+
+	allOpts := make([]RequestOption, 0, len(opts)+1)
+	allOpts = append(allOpts, opts...)
+	allOpts = append(allOpts, func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			t.transport = rt
+			return t
+		})
+	})
+	c := &defaultEndpointsClient{
+		Client:    NewClient(endpointsBaseURL, allOpts...),
+		transport: t,
+	}
+
+	if params.Resolver != nil {
+		t.resolve()
+		go t.loop(params.ResolveInterval, t.resolve)
+	}
+	if params.HealthCheckPath != "" {
+		go t.loop(params.HealthCheckInterval, t.checkHealth)
+	}
+	return c
+}
+
+func (c *defaultEndpointsClient) Endpoints() []Endpoint {
+	endpoints := c.transport.getEndpoints()
+	ret := make([]Endpoint, len(endpoints))
+	for i, e := range endpoints {
+		ret[i] = e
+	}
+	return ret
+}
+
+func (c *defaultEndpointsClient) getInitOptions() *requestOptions {
+	return requestOptionsOf(c.Client)
+}
+
+func (c *defaultEndpointsClient) Close() {
+	c.transport.closeOnce.Do(func() {
+		close(c.transport.done)
+	})
+}
+
+func (t *endpointsTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	maxAttempts := 1
+	if IsIdempotentMethod(r.Method) && (r.Body == nil || r.Body == http.NoBody || r.GetBody != nil) {
+		maxAttempts = t.params.MaxAttempts
+	}
+
+	var tried []*endpoint
+	for attempt := 1; ; attempt++ {
+		e := t.pick(r, tried)
+		if e == nil {
+			return nil, ErrNoEndpoints
+		}
+		tried = append(tried, e)
+
+		req, err := e.request(r, attempt > 1)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTrip(e, req)
+		if r.Context().Err() != nil {
+			return resp, err
+		}
+		failed := t.params.IsFailure(resp, err)
+		e.report(failed, t.params.MaxFails, t.params.EjectDuration)
+		if !failed || attempt >= maxAttempts || len(tried) >= len(t.getEndpoints()) {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+	}
+}
+
+// roundTrip sends the request to the endpoint, the request is in-flight until the response body is closed.
+func (t *endpointsTransport) roundTrip(e *endpoint, r *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&e.inFlight, 1)
+	release := func() { atomic.AddInt64(&e.inFlight, -1) }
+
+	resp, err := t.transport.RoundTrip(r)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// pick picks the endpoint except the tried ones, the unhealthy ones are picked only if all of them are unhealthy.
+func (t *endpointsTransport) pick(r *http.Request, tried []*endpoint) *endpoint {
+	var (
+		healthy   []Endpoint
+		unhealthy []Endpoint
+	)
+	for _, e := range t.getEndpoints() {
+		if containsEndpoint(tried, e) {
+			continue
+		}
+		if e.Healthy() {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = unhealthy
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	picked := t.params.Balancer.Pick(r, candidates)
+	if picked == nil {
+		return nil
+	}
+	if e, ok := picked.(*endpoint); ok {
+		return e
+	}
+	// the balancer may return its own Endpoint, such as a wrapper of the candidate
+	for _, e := range candidates {
+		if e.Addr() == picked.Addr() {
+			return e.(*endpoint)
+		}
+	}
+	return nil
+}
+
+func (t *endpointsTransport) getEndpoints() []*endpoint {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.endpoints
+}
+
+// setEndpoints replaces the endpoints, the states of the existing ones are kept.
+func (t *endpointsTransport) setEndpoints(addrs []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing := make(map[string]*endpoint, len(t.endpoints))
+	for _, e := range t.endpoints {
+		existing[e.addr] = e
+	}
+
+	endpoints := make([]*endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		if e, ok := existing[addr]; ok {
+			endpoints = append(endpoints, e)
+			continue
+		}
+		rawURL := addr
+		if !strings.HasPrefix(rawURL, "http") {
+			rawURL = "http://" + rawURL
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		endpoints = append(endpoints, &endpoint{addr: addr, url: u})
+	}
+	t.endpoints = endpoints
+}
+
+func (t *endpointsTransport) loop(interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// resolve resolves the endpoints, the current ones are kept if it's failed.
+func (t *endpointsTransport) resolve() {
+	ctx, cancel := context.WithTimeout(context.Background(), t.params.ResolveInterval)
+	defer cancel()
+
+	addrs, err := t.params.Resolver(ctx)
+	if err != nil {
+		return
+	}
+	t.setEndpoints(addrs)
+}
+
+func (t *endpointsTransport) checkHealth() {
+	var wg sync.WaitGroup
+	for _, e := range t.getEndpoints() {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			e.setCheckFailed(!t.check(e))
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (t *endpointsTransport) check(e *endpoint) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), t.params.HealthCheckTimeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointsBaseURL+t.params.HealthCheckPath, http.NoBody)
+	if err != nil {
+		return false
+	}
+	req, err := e.request(r, false)
+	if err != nil {
+		return false
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, downloadErrorMaxSize))
+	_ = resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+func (e *endpoint) Addr() string {
+	return e.addr
+}
+
+func (e *endpoint) InFlight() int64 {
+	return atomic.LoadInt64(&e.inFlight)
+}
+
+func (e *endpoint) Healthy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.checkFailed && !time.Now().Before(e.ejectedUntil)
+}
+
+// request returns the copy of r to the endpoint, the body is renewed if it's retried.
+func (e *endpoint) request(r *http.Request, retried bool) (*http.Request, error) {
+	req := r.Clone(r.Context())
+	req.URL.Scheme = e.url.Scheme
+	req.URL.Host = e.url.Host
+	req.Host = ""
+	if prefix := strings.TrimSuffix(e.url.Path, "/"); prefix != "" {
+		req.URL.Path = prefix + req.URL.Path
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = prefix + req.URL.RawPath
+		}
+	}
+	if retried && r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return req, nil
+}
+
+// report records the result of a call, the endpoint is ejected after maxFails consecutive failures.
+func (e *endpoint) report(failed bool, maxFails int, ejectDuration time.Duration) {
+	if maxFails < 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		e.fails = 0
+		return
+	}
+	e.fails++
+	if e.fails >= maxFails {
+		e.fails = 0
+		e.ejectedUntil = time.Now().Add(ejectDuration)
+	}
+}
+
+func (e *endpoint) setCheckFailed(failed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.checkFailed = failed
+	if !failed {
+		e.ejectedUntil = time.Time{}
+	}
+}
+
+func isEndpointFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func containsEndpoint(endpoints []*endpoint, e *endpoint) bool {
+	for _, item := range endpoints {
+		if item == e {
+			return true
+		}
+	}
+	return false
+}
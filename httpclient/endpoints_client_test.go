@@ -0,0 +1,244 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEndpointServer(name string, statusCode *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := int(atomic.LoadInt32(statusCode)); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, name+" "+r.URL.Path+" "+string(body))
+	}))
+}
+
+func TestEndpointsClient(t *testing.T) {
+	ast := assert.New(t)
+
+	var codes [3]int32
+	var addrs []string
+	for i, name := range []string{"a", "b", "c"} {
+		codes[i] = http.StatusOK
+		s := newTestEndpointServer(name, &codes[i])
+		defer s.Close()
+		addrs = append(addrs, s.URL)
+	}
+	addrs[2] = strings.TrimPrefix(addrs[2], "http://") + "/prefix"
+
+	c := NewEndpointsClient(EndpointsParams{Endpoints: addrs, MaxFails: 2})
+	defer c.Close()
+
+	var names []string
+	for i := 0; i < 6; i++ {
+		resp, err := c.Get("/path")
+		ast.NoError(err)
+		names = append(names, string(resp.Body()))
+	}
+	ast.Equal([]string{
+		"a /path ", "b /path ", "c /prefix/path ",
+		"a /path ", "b /path ", "c /prefix/path ",
+	}, names)
+
+	// the idempotent requests are retried on another endpoint
+	atomic.StoreInt32(&codes[0], http.StatusServiceUnavailable)
+	resp, err := c.Put("/path", "body")
+	ast.NoError(err)
+	ast.Equal(http.StatusOK, resp.StatusCode())
+	ast.True(strings.HasSuffix(string(resp.Body()), "/path body"))
+	ast.True(c.Endpoints()[0].Healthy())
+
+	// the others are not retried
+	failed := 0
+	for i := 0; i < 3; i++ {
+		resp, err := c.Post("/path", "body")
+		ast.NoError(err)
+		if resp.StatusCode() == http.StatusServiceUnavailable {
+			failed++
+		}
+	}
+	ast.Equal(1, failed)
+
+	// ejected after the consecutive failures
+	ast.False(c.Endpoints()[0].Healthy())
+	for i := 0; i < 4; i++ {
+		resp, err := c.Post("/path", "body")
+		ast.NoError(err)
+		ast.Equal(http.StatusOK, resp.StatusCode())
+	}
+
+	// the unhealthy ones are picked if all of them are unhealthy
+	for i := range codes {
+		atomic.StoreInt32(&codes[i], http.StatusBadGateway)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = c.Get("/path")
+	}
+	for _, e := range c.Endpoints() {
+		ast.False(e.Healthy(), e.Addr())
+	}
+	resp, err = c.Get("/path")
+	ast.NoError(err)
+	ast.Equal(http.StatusBadGateway, resp.StatusCode())
+
+	for _, e := range c.Endpoints() {
+		ast.EqualValues(0, e.InFlight(), e.Addr())
+	}
+}
+
+func TestEndpointsClientConnectionError(t *testing.T) {
+	ast := assert.New(t)
+
+	code := int32(http.StatusOK)
+	s := newTestEndpointServer("a", &code)
+	defer s.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	c := NewEndpointsClient(EndpointsParams{Endpoints: []string{closed.URL, s.URL}, MaxFails: 1})
+	defer c.Close()
+
+	resp, err := c.Get("/path")
+	ast.NoError(err)
+	ast.Equal("a /path ", string(resp.Body()))
+	ast.False(c.Endpoints()[0].Healthy())
+
+	_, err = c.Post("/path", nil)
+	ast.NoError(err)
+
+	c = NewEndpointsClient(EndpointsParams{})
+	_, err = c.Get("/path")
+	ast.True(errors.Is(err, ErrNoEndpoints))
+}
+
+func TestEndpointsClientCustomEndpoint(t *testing.T) {
+	ast := assert.New(t)
+
+	code := int32(http.StatusOK)
+	a, b := newTestEndpointServer("a", &code), newTestEndpointServer("b", &code)
+	defer a.Close()
+	defer b.Close()
+
+	// the balancer returns its own Endpoint which is looked up by the address
+	addr := ""
+	c := NewEndpointsClient(EndpointsParams{
+		Endpoints: []string{a.URL, b.URL},
+		Balancer: BalancerFunc(func(_ *http.Request, endpoints []Endpoint) Endpoint {
+			if addr == "" {
+				return &testEndpoint{addr: endpoints[len(endpoints)-1].Addr()}
+			}
+			return &testEndpoint{addr: addr}
+		}),
+	})
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get("/path")
+		ast.NoError(err)
+		ast.Equal("b /path ", string(resp.Body()))
+	}
+
+	addr = "unknown"
+	_, err := c.Get("/path")
+	ast.True(errors.Is(err, ErrNoEndpoints))
+}
+
+func TestEndpointsClientHealthCheck(t *testing.T) {
+	ast := assert.New(t)
+
+	var healthy int32 = 1
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, "a")
+	}))
+	defer s.Close()
+
+	code := int32(http.StatusOK)
+	other := newTestEndpointServer("b", &code)
+	defer other.Close()
+
+	c := NewEndpointsClient(EndpointsParams{
+		Endpoints:           []string{s.URL, other.URL},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 10 * time.Millisecond,
+	})
+	defer c.Close()
+
+	atomic.StoreInt32(&healthy, 0)
+	ast.Eventually(func() bool {
+		return !c.Endpoints()[0].Healthy()
+	}, time.Second, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get("/path")
+		ast.NoError(err)
+		ast.Equal("b /path ", string(resp.Body()))
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	ast.Eventually(func() bool {
+		return c.Endpoints()[0].Healthy()
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEndpointsClientResolver(t *testing.T) {
+	ast := assert.New(t)
+
+	code := int32(http.StatusOK)
+	s1 := newTestEndpointServer("a", &code)
+	defer s1.Close()
+	s2 := newTestEndpointServer("b", &code)
+	defer s2.Close()
+
+	var (
+		mu    sync.Mutex
+		addrs = []string{s1.URL}
+	)
+	c := NewEndpointsClient(EndpointsParams{
+		Resolver: func(context.Context) ([]string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if addrs == nil {
+				return nil, errors.New("resolve failed")
+			}
+			return addrs, nil
+		},
+		ResolveInterval: 10 * time.Millisecond,
+	})
+	defer c.Close()
+
+	resp, err := c.Get("/path")
+	ast.NoError(err)
+	ast.Equal("a /path ", string(resp.Body()))
+	first := c.Endpoints()[0]
+
+	mu.Lock()
+	addrs = []string{s1.URL, s2.URL}
+	mu.Unlock()
+	ast.Eventually(func() bool {
+		return len(c.Endpoints()) == 2
+	}, time.Second, 10*time.Millisecond)
+	ast.Same(first, c.Endpoints()[0], "the existing endpoints are kept")
+
+	// the endpoints are kept if it's failed to resolve
+	mu.Lock()
+	addrs = nil
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	ast.Len(c.Endpoints(), 2)
+}
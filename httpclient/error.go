@@ -8,7 +8,10 @@ import (
 	"github.com/pkg/errors"
 )
 
-var _ ResponseError = (*responseError)(nil)
+var (
+	_ ResponseError     = (*responseError)(nil)
+	_ ErrorObjectGetter = (*responseError)(nil)
+)
 
 type (
 	ResponseError interface {
@@ -17,6 +20,12 @@ type (
 		IsStatusCode(statusCode int) bool
 	}
 
+	// ErrorObjectGetter is implemented by the ResponseError of this package, see GetErrorObject.
+	ErrorObjectGetter interface {
+		// GetErrorObject returns the decoded error object, see WithErrorObject.
+		GetErrorObject() interface{}
+	}
+
 	responseError struct {
 		error
 		resp *resty.Response
@@ -27,11 +36,17 @@ func NewResponseError(resp *resty.Response, err error) error {
 	return errors.WithStack(&responseError{resp: resp, error: err})
 }
 
-func NewResponseErrorNotSuccess(resp *resty.Response) error {
+// NewResponseErrorNotSuccess returns the ResponseError if resp is not success,
+// the cause is converted from the error object by the ErrorConverter of opts, see WithErrorConverter.
+func NewResponseErrorNotSuccess(resp *resty.Response, opts ...RequestOption) error {
+	return newResponseErrorNotSuccess(resp, newRequestOptions(opts...))
+}
+
+func newResponseErrorNotSuccess(resp *resty.Response, o *requestOptions) error {
 	if resp == nil || resp.IsSuccess() {
 		return nil
 	}
-	return NewResponseError(resp, nil)
+	return NewResponseError(resp, o.convertErrorObject(resp))
 }
 
 func AsResponseError(err error) (ResponseError, bool) {
@@ -61,6 +76,13 @@ func (e *responseError) GetResponse() *resty.Response {
 	return e.resp
 }
 
+func (e *responseError) GetErrorObject() interface{} {
+	if e.resp == nil || e.resp.Request == nil {
+		return nil
+	}
+	return e.resp.Request.Error
+}
+
 func (e *responseError) IsStatusCode(statusCode int) bool {
 	return e.GetResponse().StatusCode() == statusCode
 }
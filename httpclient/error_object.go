@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"reflect"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+type (
+	// StandardErrorObject is the error body of the standard response, see response.NewStandardHandler.
+	StandardErrorObject struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Details string `json:"details,omitempty"`
+	}
+
+	// ErrorConverter converts the decoded error object of the response into an error, such as errorx.CodeError.
+	ErrorConverter func(resp *resty.Response, errorObject interface{}) error
+
+	// ErrCodeLookup returns the registered *errorx.ErrCode of the remote code, nil if it's not registered.
+	ErrCodeLookup func(code int) *errorx.ErrCode
+
+	// optionsGetter is implemented by the clients which hold the init options.
+	optionsGetter interface {
+		getInitOptions() *requestOptions
+	}
+)
+
+// WithErrorObject decodes the body of the non-2xx response into a new object of the type of v,
+// it's available by GetErrorObject and resty.Request.Error.
+// For examples:
+//
+//	err := c.Get("/", &obj, WithErrorObject(&StandardErrorObject{}))
+//	errObj, _ := GetErrorObject(err).(*StandardErrorObject)
+func WithErrorObject(v interface{}) RequestOption {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return func(o *requestOptions) {
+		o.errorObjectType = t
+	}
+}
+
+// GetErrorObject returns the decoded error object of the ResponseError in err, nil if there is none.
+func GetErrorObject(err error) interface{} {
+	var e ErrorObjectGetter
+	if errors.As(err, &e) {
+		return e.GetErrorObject()
+	}
+	return nil
+}
+
+// WithErrorConverter converts the decoded error object into the cause of ResponseError, see WithErrorObject.
+func WithErrorConverter(fn ErrorConverter) RequestOption {
+	return func(o *requestOptions) {
+		o.errorConverter = fn
+	}
+}
+
+// StandardErrorConverter converts the *StandardErrorObject into errorx.CodeError with a new *errorx.ErrCode
+// of the remote code, which can not be matched by errorx.IsCodeError, see NewStandardErrorConverter.
+func StandardErrorConverter(resp *resty.Response, errorObject interface{}) error {
+	return NewStandardErrorConverter(nil)(resp, errorObject)
+}
+
+// NewStandardErrorConverter creates the ErrorConverter which converts the *StandardErrorObject into errorx.CodeError,
+// the code is looked up by lookup so it can be matched by errorx.IsCodeError.
+// A new *errorx.ErrCode of the remote code is used if lookup is nil or the code is not registered.
+func NewStandardErrorConverter(lookup ErrCodeLookup) ErrorConverter {
+	return func(_ *resty.Response, errorObject interface{}) error {
+		obj, ok := errorObject.(*StandardErrorObject)
+		if !ok || obj.Code == 0 {
+			return nil
+		}
+
+		var code *errorx.ErrCode
+		if lookup != nil {
+			code = lookup(obj.Code)
+		}
+		if code == nil {
+			categoryCode, platformCode, specificCode := errorx.SeparateCode(obj.Code)
+			code = errorx.NewErrCode(categoryCode, platformCode, specificCode, obj.Message)
+		}
+		if obj.Details == "" {
+			return errorx.WithCode(code, nil)
+		}
+		return errorx.WithCode(code, nil, "%s", obj.Details)
+	}
+}
+
+// NewErrCodeLookup creates the ErrCodeLookup of the registered codes.
+func NewErrCodeLookup(codes ...*errorx.ErrCode) ErrCodeLookup {
+	m := make(map[int]*errorx.ErrCode, len(codes))
+	for _, c := range codes {
+		m[c.GetCode()] = c
+	}
+	return func(code int) *errorx.ErrCode {
+		return m[code]
+	}
+}
+
+// decodeErrorObject decodes the error object of the non-2xx response.
+func (o *requestOptions) decodeErrorObject(r *resty.Request, resp *resty.Response) {
+	if o.errorObjectType == nil || resp == nil || resp.IsSuccess() {
+		return
+	}
+
+	errorObject := reflect.New(o.errorObjectType).Interface()
+	if err := decodeResponse(resp, errorObject); err != nil {
+		return
+	}
+	r.Error = errorObject
+}
+
+// convertErrorObject converts the error object of resp by the ErrorConverter.
+func (o *requestOptions) convertErrorObject(resp *resty.Response) error {
+	if o.errorConverter == nil || resp.Request == nil || resp.Request.Error == nil {
+		return nil
+	}
+	return o.errorConverter(resp, resp.Request.Error)
+}
+
+// requestOptionsOf returns the options of the request sent by cli, including the init options of cli.
+func requestOptionsOf(cli Client, opts ...RequestOption) *requestOptions {
+	if g, ok := cli.(optionsGetter); ok {
+		return g.getInitOptions().WithOptions(opts...)
+	}
+	return newRequestOptions(opts...)
+}
@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithErrorObject(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = io.WriteString(w, `{"code":0,"message":"Success"}`)
+		case "/invalid":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = io.WriteString(w, `invalid`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"code":40401001,"message":"ErrUserNotFound","details":"user 1"}`)
+		}
+	}))
+	defer testServer.Close()
+
+	// object client
+	c := NewObjectClient(testServer.URL, WithErrorObject(StandardErrorObject{}))
+	var obj StandardErrorObject
+	ast.NoError(c.Get("/ok", &obj))
+
+	err := c.Get("/", &obj)
+	ast.True(IsResponseError(err, http.StatusNotFound))
+	ast.Equal(&StandardErrorObject{Code: 40401001, Message: "ErrUserNotFound", Details: "user 1"}, GetErrorObject(err))
+	ast.Nil(GetErrorObject(errors.New("not response error")))
+	ast.False(errorx.IsCodeError(err))
+
+	err = c.Get("/invalid", &obj)
+	ast.True(IsResponseError(err))
+	ast.Nil(GetErrorObject(err))
+
+	// bytes client with converter
+	bc := NewBytesClient(testServer.URL, WithErrorObject(&StandardErrorObject{}), WithErrorConverter(StandardErrorConverter))
+	_, err = bc.Get("/")
+	ast.True(IsResponseError(err, http.StatusNotFound))
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(40401001, e.GetCode())
+		ast.Equal(404, e.GetCategoryCode())
+		ast.Equal(1, e.GetPlatformCode())
+		ast.Equal(1, e.GetSpecificCode())
+		ast.Equal("ErrUserNotFound", e.GetMessage())
+		ast.Equal("user 1", e.GetDetails())
+	}
+
+	// the converter per request and the registered codes
+	errUserNotFound := errorx.NewErrCode(404, 1, 1, "ErrUserNotFound")
+	bc = NewBytesClient(testServer.URL, WithErrorObject(&StandardErrorObject{}))
+	_, err = bc.Get("/", WithErrorConverter(NewStandardErrorConverter(NewErrCodeLookup(errUserNotFound))))
+	ast.True(errorx.IsCodeError(err, errUserNotFound))
+
+	// without error object
+	_, err = NewBytesClient(testServer.URL, WithErrorConverter(StandardErrorConverter)).Get("/")
+	ast.True(IsResponseError(err))
+	ast.Nil(GetErrorObject(err))
+	ast.False(errorx.IsCodeError(err))
+}
+
+func TestStandardErrorConverter(t *testing.T) {
+	ast := assert.New(t)
+
+	ast.NoError(StandardErrorConverter(nil, nil))
+	ast.NoError(StandardErrorConverter(nil, &StandardErrorObject{}))
+	ast.NoError(StandardErrorConverter(nil, &struct{}{}))
+
+	err := StandardErrorConverter(nil, &StandardErrorObject{Code: 50000000, Message: "ErrInternalServer"})
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(50000000, e.GetCode())
+		ast.Equal("ErrInternalServer", e.GetMessage())
+		ast.Equal("", e.GetDetails())
+	}
+
+	resp := &resty.Response{RawResponse: &http.Response{StatusCode: http.StatusNotFound}}
+	ast.NoError(newRequestOptions(WithErrorConverter(StandardErrorConverter)).convertErrorObject(resp))
+}
+
+func TestNewStandardErrorConverter(t *testing.T) {
+	ast := assert.New(t)
+
+	errUserNotFound := errorx.NewErrCode(404, 1, 1, "ErrUserNotFound")
+	converter := NewStandardErrorConverter(NewErrCodeLookup(errUserNotFound))
+
+	ast.NoError(converter(nil, &StandardErrorObject{}))
+
+	err := converter(nil, &StandardErrorObject{Code: 40401001, Message: "remote message", Details: "user 1"})
+	ast.True(errorx.IsCodeError(err, errUserNotFound))
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal("ErrUserNotFound", e.GetMessage())
+		ast.Equal("user 1", e.GetDetails())
+	}
+
+	err = converter(nil, &StandardErrorObject{Code: 50000000, Message: "ErrInternalServer"})
+	ast.False(errorx.IsCodeError(err, errUserNotFound))
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(50000000, e.GetCode())
+	}
+}
@@ -0,0 +1,302 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	DefaultHedgingMaxAttempts = 2
+	DefaultHedgingDelay       = 100 * time.Millisecond
+	DefaultHedgingMinSamples  = 20
+	DefaultHedgingWindowSize  = 1000
+)
+
+type (
+	HedgingParams struct {
+		// MaxAttempts is the max number of copies of the request including the first one,
+		// default is DefaultHedgingMaxAttempts.
+		MaxAttempts int
+		// Delay is the delay before sending the next copy if no response is returned, default is DefaultHedgingDelay.
+		Delay time.Duration
+		// Percentile uses the percentile of the recent latencies as the delay, such as 0.95,
+		// the Delay is used until MinSamples latencies are recorded. It's default 0 means the Delay is always used.
+		Percentile float64
+		// MinSamples is the min number of latencies to use the Percentile, default is DefaultHedgingMinSamples.
+		MinSamples int
+		// WindowSize is the number of recent latencies kept, default is DefaultHedgingWindowSize.
+		WindowSize int
+	}
+
+	// hedging is shared by the requests with the same option, so the latencies are recorded across them.
+	hedging struct {
+		params    HedgingParams
+		mu        sync.Mutex
+		latencies []time.Duration
+		next      int
+	}
+
+	hedgingResult struct {
+		attempt int
+		r       *resty.Request
+		resp    *resty.Response
+		err     error
+		latency time.Duration
+	}
+
+	hedgedAttemptCtxKey struct{}
+
+	// cancelOnCloseBody cancels the context of the hedged copy after the body is closed.
+	cancelOnCloseBody struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+// WithHedging sends another copy of the idempotent request if no response is returned after a delay,
+// the first response returned is used and the other copies are canceled.
+// The failed copy makes the next one sent immediately, and the last error is returned if all of them are failed.
+// The requests with the body of io.Reader or multipart are not hedged, see HedgedAttempt for the copy used.
+func WithHedging(params HedgingParams) RequestOption {
+	if params.MaxAttempts <= 0 {
+		params.MaxAttempts = DefaultHedgingMaxAttempts
+	}
+	if params.Delay <= 0 {
+		params.Delay = DefaultHedgingDelay
+	}
+	if params.MinSamples <= 0 {
+		params.MinSamples = DefaultHedgingMinSamples
+	}
+	if params.WindowSize <= 0 {
+		params.WindowSize = DefaultHedgingWindowSize
+	}
+
+	h := &hedging{
+		params: params,
+	}
+	return func(o *requestOptions) {
+		o.hedging = h
+	}
+}
+
+// HedgedAttempt returns the copy of the hedged request which the response comes from, starting from 1.
+// It's available in the afterRequestHook, and false is returned if the request is not hedged.
+func HedgedAttempt(r *resty.Request) (int, bool) {
+	if r == nil {
+		return 0, false
+	}
+	attempt, ok := r.Context().Value(hedgedAttemptCtxKey{}).(int)
+	return attempt, ok
+}
+
+// executeAttempt executes one attempt of the request, which is hedged if it's enabled.
+func (c *defaultClient) executeAttempt(
+	o *requestOptions, method, urlPath string, prepare func(*resty.Request) error,
+) (*resty.Request, *resty.Response, error) {
+	if o.hedging != nil && o.hedging.params.MaxAttempts > 1 && IsIdempotentMethod(method) && len(o.multipartParts) == 0 {
+		return c.executeHedged(o, method, urlPath, prepare)
+	}
+	return c.executeOnce(o, method, urlPath, prepare)
+}
+
+func (c *defaultClient) executeHedged(
+	o *requestOptions, method, urlPath string, prepare func(*resty.Request) error,
+) (*resty.Request, *resty.Response, error) {
+	if o.uploadProgress != nil {
+		o = o.WithOptions(WithUploadProgress(hedgedProgress(o.uploadProgress)))
+	}
+
+	var (
+		h           = o.hedging
+		maxAttempts = h.params.MaxAttempts
+		results     = make(chan *hedgingResult, maxAttempts)
+		hedgeable   = make(chan bool, 1)
+		mu          sync.Mutex
+		cancels     = make([]context.CancelFunc, maxAttempts+1)
+		finished    bool
+	)
+
+	launch := func(attempt int) {
+		go func() {
+			startTime := time.Now()
+			r, resp, err := c.executeOnce(o, method, urlPath, func(r *resty.Request) error {
+				ctx, cancel := context.WithCancel(r.Context())
+				r.SetContext(context.WithValue(ctx, hedgedAttemptCtxKey{}, attempt))
+				mu.Lock()
+				cancels[attempt] = cancel
+				if finished {
+					cancel() // launched before finishing, but prepared after it
+				}
+				mu.Unlock()
+
+				if attempt == 1 {
+					_, isReader := r.Body.(io.Reader)
+					hedgeable <- !isReader
+				}
+				if prepare != nil {
+					return prepare(r)
+				}
+				return nil
+			})
+			results <- &hedgingResult{attempt: attempt, r: r, resp: resp, err: err, latency: time.Since(startTime)}
+		}()
+	}
+
+	var (
+		launched = 1
+		pending  = 1
+	)
+	// finish cancels the copies except the winner, and drains the pending ones in background.
+	finish := func(winner *hedgingResult) {
+		mu.Lock()
+		finished = true
+		for attempt, cancel := range cancels {
+			if cancel != nil && attempt != winner.attempt {
+				cancel()
+			}
+		}
+		cancel := cancels[winner.attempt]
+		mu.Unlock()
+
+		if cancel != nil {
+			if body := unparsedBody(winner.resp); body != nil {
+				// the body is read by the caller, so cancel it after the body is closed
+				winner.resp.RawResponse.Body = &cancelOnCloseBody{ReadCloser: body, cancel: cancel}
+			} else {
+				cancel()
+			}
+		}
+
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				res := <-results
+				if body := unparsedBody(res.resp); body != nil {
+					_ = body.Close()
+				}
+			}
+		}(pending)
+	}
+
+	launch(1)
+	select {
+	case ok := <-hedgeable:
+		if !ok {
+			maxAttempts = 1
+		}
+	case res := <-results: // failed before sending, or returned before the hedgeable is received
+		pending--
+		if res.err == nil {
+			h.record(res.latency)
+		}
+		finish(res)
+		return res.r, res.resp, res.err
+	}
+
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+	for {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				h.record(res.latency)
+				finish(res)
+				return res.r, res.resp, res.err
+			}
+			if launched < maxAttempts && res.r.Context().Err() == nil {
+				launched++
+				pending++
+				launch(launched)
+				continue
+			}
+			if pending == 0 {
+				finish(res)
+				return res.r, res.resp, res.err
+			}
+		case <-timer.C:
+			if launched < maxAttempts {
+				launched++
+				pending++
+				launch(launched)
+				timer.Reset(h.delay())
+			}
+		}
+	}
+}
+
+// delay returns the delay before sending the next copy.
+func (h *hedging) delay() time.Duration {
+	if h.params.Percentile <= 0 {
+		return h.params.Delay
+	}
+
+	h.mu.Lock()
+	if len(h.latencies) < h.params.MinSamples {
+		h.mu.Unlock()
+		return h.params.Delay
+	}
+	latencies := make([]time.Duration, len(h.latencies))
+	copy(latencies, h.latencies)
+	h.mu.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	index := int(math.Ceil(h.params.Percentile*float64(len(latencies)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(latencies) {
+		index = len(latencies) - 1
+	}
+	return latencies[index]
+}
+
+// record records the latency in the window of recent latencies.
+func (h *hedging) record(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < h.params.WindowSize {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % h.params.WindowSize
+}
+
+// hedgedProgress reports the upload progress of the hedged copies once, only the max transferred is reported.
+func hedgedProgress(fn ProgressFunc) ProgressFunc {
+	var (
+		mu       sync.Mutex
+		reported int64 = -1
+	)
+	return func(transferred, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		if transferred > reported {
+			reported = transferred
+			fn(transferred, total)
+		}
+	}
+}
+
+// unparsedBody returns the raw body of the response which is not read by resty, such as SetDoNotParseResponse.
+func unparsedBody(resp *resty.Response) io.ReadCloser {
+	if resp == nil || resp.RawResponse == nil || resp.RawResponse.Body == nil || resp.Body() != nil {
+		return nil
+	}
+	return resp.RawResponse.Body
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,203 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHedging(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls    int32
+		canceled int32
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			select {
+			case <-r.Context().Done():
+				atomic.AddInt32(&canceled, 1)
+				return
+			case <-time.After(time.Second):
+			}
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer testServer.Close()
+
+	var attempt int
+	c := NewClient(testServer.URL,
+		WithHedging(HedgingParams{Delay: 20 * time.Millisecond}),
+		WithAfterRequestHook(func(r *resty.Request, _ *resty.Response, _ error) {
+			attempt, _ = HedgedAttempt(r)
+		}),
+	)
+
+	startTime := time.Now()
+	resp, err := c.Get("/")
+	ast.NoError(err)
+	ast.Equal("ok", string(resp.Body()))
+	ast.Less(int64(time.Since(startTime)), int64(500*time.Millisecond))
+	ast.Equal(2, attempt)
+	ast.Eventually(func() bool {
+		return atomic.LoadInt32(&canceled) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the fast one is not hedged
+	atomic.StoreInt32(&calls, 1)
+	resp, err = c.Get("/")
+	ast.NoError(err)
+	ast.Equal("ok", string(resp.Body()))
+	ast.Equal(1, attempt)
+	ast.EqualValues(2, atomic.LoadInt32(&calls))
+
+	// the non-idempotent requests and the io.Reader bodies are not hedged
+	for _, fn := range []func() (*resty.Response, error){
+		func() (*resty.Response, error) { return c.Post("/", "body") },
+		func() (*resty.Response, error) { return c.Put("/", bytes.NewReader([]byte("body"))) },
+	} {
+		atomic.StoreInt32(&calls, 0)
+		attempt = 0
+		resp, err = fn()
+		ast.NoError(err)
+		ast.Equal("ok", string(resp.Body()))
+		ast.EqualValues(1, atomic.LoadInt32(&calls))
+	}
+}
+
+func TestWithHedgingCleanup(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = io.WriteString(w, "o")
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		_, _ = io.WriteString(w, "k")
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithHedging(HedgingParams{Delay: 10 * time.Millisecond}))
+
+	// the context of the streaming winner is canceled after the body is closed
+	resp, err := c.Get("/", WithBeforeRequestHook(func(r *resty.Request) {
+		r.SetDoNotParseResponse(true)
+	}))
+	ast.NoError(err)
+	ast.NoError(resp.Request.Context().Err())
+	body, err := io.ReadAll(resp.RawBody())
+	ast.NoError(err)
+	ast.Equal("ok", string(body))
+	ast.NoError(resp.RawBody().Close())
+	ast.Error(resp.Request.Context().Err())
+
+	// the upload progress is reported once for the copies
+	var transferred []int64
+	_, err = c.Put("/", []byte("content"), WithUploadProgress(func(n, _ int64) {
+		transferred = append(transferred, n)
+	}))
+	ast.NoError(err)
+	ast.EqualValues(4, atomic.LoadInt32(&calls))
+	ast.Equal([]int64{7}, transferred)
+}
+
+func TestWithHedgingFailed(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	hook := WithBeforeRequestHook(func(*resty.Request) {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	// the failed copy makes the next one sent immediately
+	c := NewClient(closed.URL, WithHedging(HedgingParams{MaxAttempts: 3, Delay: time.Minute}), hook)
+	startTime := time.Now()
+	_, err := c.Get("/")
+	ast.Error(err)
+	ast.Less(int64(time.Since(startTime)), int64(time.Second))
+	ast.EqualValues(3, atomic.LoadInt32(&calls))
+
+	// canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.Get("/", WithContext(ctx))
+	ast.ErrorIs(err, context.Canceled)
+	ast.EqualValues(1, atomic.LoadInt32(&calls))
+}
+
+func TestWithHedgingEndpoints(t *testing.T) {
+	ast := assert.New(t)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_, _ = io.WriteString(w, "slow")
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "fast")
+	}))
+	defer fast.Close()
+
+	var attempt int
+	c := NewEndpointsClient(EndpointsParams{Endpoints: []string{slow.URL, fast.URL}},
+		WithHedging(HedgingParams{Delay: 20 * time.Millisecond}),
+		WithAfterRequestHook(func(r *resty.Request, _ *resty.Response, _ error) {
+			attempt, _ = HedgedAttempt(r)
+		}),
+	)
+	defer c.Close()
+
+	// the copies are sent to the endpoints in turn
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get("/")
+		ast.NoError(err)
+		ast.Equal("fast", string(resp.Body()))
+		ast.Equal(2, attempt)
+	}
+}
+
+func TestHedgingDelay(t *testing.T) {
+	ast := assert.New(t)
+
+	h := &hedging{params: HedgingParams{Delay: time.Second, Percentile: 0.9, MinSamples: 10, WindowSize: 20}}
+	ast.Equal(time.Second, h.delay())
+
+	for i := 1; i <= 10; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	ast.Equal(9*time.Millisecond, h.delay())
+
+	for i := 0; i < 20; i++ {
+		h.record(time.Millisecond)
+	}
+	ast.Len(h.latencies, 20)
+	ast.Equal(time.Millisecond, h.delay())
+
+	h.params.Percentile = 1
+	h.record(5 * time.Millisecond)
+	ast.Equal(5*time.Millisecond, h.delay())
+
+	h.params.Percentile = 0
+	ast.Equal(time.Second, h.delay())
+}
@@ -0,0 +1,270 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FakeAddr is the address of the clients created by FakeServer, any address works with FakeServer.
+const FakeAddr = "http://fake.local"
+
+var _ http.RoundTripper = (*FakeServer)(nil)
+
+type (
+	// FakeServer is the in-memory http.RoundTripper which serves the requests by the route table,
+	// point the clients at it by httpclient.WithTransport, no sockets are opened.
+	// The request without route is responded 404.
+	FakeServer struct {
+		mu     sync.Mutex
+		routes map[string]*fakeRoute
+		calls  []*Call
+	}
+
+	// Response is the canned response.
+	// The Body of string and []byte is written as is, and the others are encoded as json.
+	Response struct {
+		StatusCode int
+		Header     http.Header
+		Body       interface{}
+	}
+
+	// Call is the request received by FakeServer.
+	Call struct {
+		Method string
+		Path   string
+		Query  url.Values
+		Header http.Header
+		Body   []byte
+	}
+
+	fakeRoute struct {
+		handler   http.Handler
+		responses []*Response
+		next      int
+	}
+)
+
+func NewFakeServer() *FakeServer {
+	return &FakeServer{
+		routes: map[string]*fakeRoute{},
+	}
+}
+
+// Handle routes the requests of method and path to h, the empty method matches any method.
+// The route of the same method and path is replaced.
+func (s *FakeServer) Handle(method, path string, h http.Handler) *FakeServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[routeKey(method, path)] = &fakeRoute{handler: h}
+	return s
+}
+
+// HandleFunc is as same as Handle but with the handler function.
+func (s *FakeServer) HandleFunc(method, path string, fn func(w http.ResponseWriter, r *http.Request)) *FakeServer {
+	return s.Handle(method, path, http.HandlerFunc(fn))
+}
+
+// Respond routes the requests of method and path to the canned responses, the empty method matches any method.
+// The responses are responded in order, and the last one is repeated. It panics if there is no response.
+func (s *FakeServer) Respond(method, path string, responses ...*Response) *FakeServer {
+	if len(responses) == 0 {
+		panic("httpclienttest: no responses of " + routeKey(method, path))
+	}
+	for _, resp := range responses {
+		if resp == nil {
+			panic("httpclienttest: nil response of " + routeKey(method, path))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[routeKey(method, path)] = &fakeRoute{responses: responses}
+	return s
+}
+
+// RespondJSON routes the requests of method and path to the response of statusCode with body encoded as json.
+func (s *FakeServer) RespondJSON(method, path string, statusCode int, body interface{}) *FakeServer {
+	return s.Respond(method, path, &Response{StatusCode: statusCode, Body: body})
+}
+
+func (s *FakeServer) Client(opts ...httpclient.RequestOption) httpclient.Client {
+	return httpclient.NewClient(FakeAddr, s.options(opts)...)
+}
+
+func (s *FakeServer) BytesClient(opts ...httpclient.RequestOption) httpclient.BytesClient {
+	return httpclient.NewBytesClient(FakeAddr, s.options(opts)...)
+}
+
+func (s *FakeServer) ObjectClient(opts ...httpclient.RequestOption) httpclient.ObjectClient {
+	return httpclient.NewObjectClient(FakeAddr, s.options(opts)...)
+}
+
+// Calls returns the calls received, the empty method and path match any one.
+func (s *FakeServer) Calls(method, path string) []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []*Call
+	for _, c := range s.calls {
+		if (method == "" || c.Method == method) && (path == "" || c.Path == path) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset clears the calls received and rewinds the canned responses, the routes are kept.
+func (s *FakeServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	for _, route := range s.routes {
+		route.next = 0
+	}
+}
+
+func (s *FakeServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, &Call{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	route, ok := s.routes[routeKey(r.Method, r.URL.Path)]
+	if !ok {
+		route, ok = s.routes[routeKey("", r.URL.Path)]
+	}
+	var canned *Response
+	if ok && route.handler == nil {
+		canned = route.responses[route.next]
+		if route.next < len(route.responses)-1 {
+			route.next++
+		}
+	}
+	s.mu.Unlock()
+
+	req := r.Clone(r.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.RequestURI = r.URL.RequestURI()
+
+	rec := httptest.NewRecorder()
+	switch {
+	case !ok:
+		http.NotFound(rec, req)
+	case route.handler != nil:
+		route.handler.ServeHTTP(rec, req)
+	default:
+		if err := canned.write(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// AssertCalled asserts that the method and path is called at least once.
+func AssertCalled(t testing.TB, s *FakeServer, method, path string) bool {
+	t.Helper()
+	return assert.NotEmpty(t, s.Calls(method, path), "calls of %s %s", method, path)
+}
+
+// AssertNotCalled asserts that the method and path is never called.
+func AssertNotCalled(t testing.TB, s *FakeServer, method, path string) bool {
+	t.Helper()
+	return assert.Empty(t, s.Calls(method, path), "calls of %s %s", method, path)
+}
+
+// AssertCallCount asserts the number of calls of the method and path.
+func AssertCallCount(t testing.TB, s *FakeServer, method, path string, expected int) bool {
+	t.Helper()
+	return assert.Len(t, s.Calls(method, path), expected, "calls of %s %s", method, path)
+}
+
+// AssertCallJSON asserts that the last call of the method and path has the body equal to expectedBody in json.
+func AssertCallJSON(t testing.TB, s *FakeServer, method, path string, expectedBody interface{}) bool {
+	t.Helper()
+
+	calls := s.Calls(method, path)
+	if !assert.NotEmpty(t, calls, "calls of %s %s", method, path) {
+		return false
+	}
+	expected, err := json.Marshal(expectedBody)
+	if !assert.NoError(t, err, "json.Marshal expected body") {
+		return false
+	}
+	return assert.JSONEq(t, string(expected), string(calls[len(calls)-1].Body), "body of %s %s", method, path)
+}
+
+func (s *FakeServer) options(opts []httpclient.RequestOption) []httpclient.RequestOption {
+	allOpts := make([]httpclient.RequestOption, 0, len(opts)+1)
+	allOpts = append(allOpts, httpclient.WithTransport(s))
+	allOpts = append(allOpts, opts...)
+	return allOpts
+}
+
+func (resp *Response) write(w http.ResponseWriter) error {
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+
+	var body []byte
+	switch v := resp.Body.(type) {
+	case nil:
+	case []byte:
+		body = v
+	case string:
+		body = []byte(v)
+	default:
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		body = bs
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", httpclient.ContentTypeJSON)
+		}
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+	_, err := w.Write(body)
+	return err
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
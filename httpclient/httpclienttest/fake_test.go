@@ -0,0 +1,141 @@
+package httpclienttest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vesoft-inc/go-pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTB struct {
+	testing.TB
+	failed bool
+}
+
+func (t *testTB) Errorf(string, ...interface{}) {
+	t.failed = true
+}
+
+func TestFakeServer(t *testing.T) {
+	ast := assert.New(t)
+
+	s := NewFakeServer().
+		RespondJSON(http.MethodGet, "/users/1", http.StatusOK, &testUser{ID: 1, Name: "n"}).
+		Respond(http.MethodGet, "/text", &Response{
+			Header: http.Header{"Content-Type": []string{"text/plain"}},
+			Body:   "hello",
+		}).
+		HandleFunc(http.MethodPost, "/users", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Query", r.URL.Query().Get("k"))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		}).
+		Respond("", "/any", &Response{StatusCode: http.StatusAccepted})
+
+	// ObjectClient
+	var user testUser
+	ast.NoError(s.ObjectClient().Get("/users/1", &user))
+	ast.Equal(testUser{ID: 1, Name: "n"}, user)
+
+	user = testUser{}
+	ast.NoError(s.ObjectClient().Post("/users", &testUser{ID: 2, Name: "n2"}, &user))
+	ast.Equal(testUser{ID: 2, Name: "n2"}, user)
+
+	// BytesClient
+	data, err := s.BytesClient().Get("/text")
+	ast.NoError(err)
+	ast.Equal("hello", string(data))
+
+	_, err = s.BytesClient().Get("/not-found")
+	if e, ok := httpclient.AsResponseError(err); ast.True(ok) {
+		ast.True(e.IsStatusCode(http.StatusNotFound))
+	}
+
+	// Client
+	resp, err := s.Client().Post("/users", `{"name":"n3"}`, httpclient.WithQueryParam("k", "v"))
+	ast.NoError(err)
+	ast.Equal(http.StatusCreated, resp.StatusCode())
+	ast.Equal("v", resp.Header().Get("X-Query"))
+	ast.Equal(`{"name":"n3"}`, string(resp.Body()))
+
+	resp, err = s.Client().Delete("/any", nil)
+	ast.NoError(err)
+	ast.Equal(http.StatusAccepted, resp.StatusCode())
+
+	resp, err = s.Client().Delete("/users/1", nil)
+	ast.NoError(err)
+	ast.Equal(http.StatusNotFound, resp.StatusCode())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = s.Client().Get("/users/1", httpclient.WithContext(ctx))
+	ast.Error(err)
+
+	// calls
+	ast.Len(s.Calls("", ""), 7)
+	calls := s.Calls(http.MethodPost, "/users")
+	if ast.Len(calls, 2) {
+		ast.JSONEq(`{"id":2,"name":"n2"}`, string(calls[0].Body))
+		ast.Equal("v", calls[1].Query.Get("k"))
+	}
+	ast.True(AssertCalled(t, s, http.MethodGet, "/users/1"))
+	ast.True(AssertNotCalled(t, s, http.MethodPut, "/users/1"))
+	ast.True(AssertCallCount(t, s, "", "/users", 2))
+	ast.True(AssertCallJSON(t, s, http.MethodPost, "/users", map[string]string{"name": "n3"}))
+
+	tb := &testTB{TB: t}
+	ast.False(AssertCalled(tb, s, http.MethodPut, "/users/1"))
+	ast.False(AssertNotCalled(tb, s, http.MethodGet, "/users/1"))
+	ast.False(AssertCallCount(tb, s, http.MethodGet, "/users/1", 2))
+	ast.False(AssertCallJSON(tb, s, http.MethodPut, "/users/1", nil))
+	ast.True(tb.failed)
+
+	s.Reset()
+	ast.Empty(s.Calls("", ""))
+}
+
+func TestFakeServerResponses(t *testing.T) {
+	ast := assert.New(t)
+
+	s := NewFakeServer().Respond(http.MethodGet, "/flaky",
+		&Response{StatusCode: http.StatusServiceUnavailable},
+		&Response{StatusCode: http.StatusServiceUnavailable},
+		&Response{Body: "ok"},
+	)
+	c := s.BytesClient(httpclient.WithRetry(httpclient.RetryPolicy{MaxAttempts: 5, InitialInterval: time.Millisecond}))
+
+	data, err := c.Get("/flaky")
+	ast.NoError(err)
+	ast.Equal("ok", string(data))
+	AssertCallCount(t, s, http.MethodGet, "/flaky", 3)
+
+	// the last response is repeated
+	data, err = c.Get("/flaky")
+	ast.NoError(err)
+	ast.Equal("ok", string(data))
+	AssertCallCount(t, s, http.MethodGet, "/flaky", 4)
+
+	// rewind the responses
+	s.Reset()
+	resp, err := s.Client().Get("/flaky")
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+}
+
+func TestFakeServerRespondEmpty(t *testing.T) {
+	ast := assert.New(t)
+
+	s := NewFakeServer()
+	ast.Panics(func() {
+		s.Respond(http.MethodGet, "/empty")
+	})
+	ast.Panics(func() {
+		s.Respond(http.MethodGet, "/nil", &Response{}, nil)
+	})
+}
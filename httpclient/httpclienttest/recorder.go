@@ -0,0 +1,353 @@
+// Package httpclienttest provides utilities for testing the code which uses httpclient.
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/vesoft-inc/go-pkg/httpclient"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ModeReplay replays the interactions of the cassette, the cassette must exist.
+	ModeReplay Mode = iota
+	// ModeRecord sends the real requests and records the interactions, the cassette is overwritten.
+	ModeRecord
+	// ModeReplayOrRecord replays the matched interactions, and records the unmatched ones.
+	ModeReplayOrRecord
+
+	// BodyEncodingBase64 is the encoding of the recorded bodies which are binary or not valid UTF-8.
+	BodyEncodingBase64 = "base64"
+)
+
+var (
+	_ Recorder = (*defaultRecorder)(nil)
+
+	record = flag.Bool("httpclienttest.record", false, "record the cassettes of httpclienttest")
+
+	ErrInteractionNotFound = errors.New("interaction not found")
+)
+
+type (
+	Mode int
+
+	// Cassette is the recorded interactions which is saved as a json file.
+	Cassette struct {
+		Interactions []*Interaction `json:"interactions"`
+	}
+
+	Interaction struct {
+		Request  *RecordedRequest  `json:"request"`
+		Response *RecordedResponse `json:"response"`
+	}
+
+	RecordedRequest struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header,omitempty"`
+		Body   string      `json:"body,omitempty"`
+		// BodyEncoding is BodyEncodingBase64 if the body is base64 encoded, otherwise it's empty.
+		BodyEncoding string `json:"bodyEncoding,omitempty"`
+	}
+
+	RecordedResponse struct {
+		StatusCode int         `json:"statusCode"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		// BodyEncoding is BodyEncodingBase64 if the body is base64 encoded, otherwise it's empty.
+		BodyEncoding string `json:"bodyEncoding,omitempty"`
+	}
+
+	// MatcherFunc reports whether the request matches the recorded one, the request is redacted as the recorded one.
+	MatcherFunc func(r, recorded *RecordedRequest) bool
+
+	RecorderParams struct {
+		// Path is the path of the cassette file.
+		Path string
+		Mode Mode
+		// Transport sends the real requests, default is http.DefaultTransport.
+		Transport http.RoundTripper
+		// Matcher matches the requests with the recorded ones, default is DefaultMatcher.
+		Matcher MatcherFunc
+		// Passthrough sends the unmatched requests by the Transport without recording in ModeReplay,
+		// otherwise they fail with ErrInteractionNotFound.
+		Passthrough bool
+		// RedactHeaders are the headers to redact in the cassette, default is httpclient.DefaultRedactHeaders.
+		RedactHeaders []string
+		// RedactQueryParams are the query params to redact in the cassette,
+		// default is httpclient.DefaultRedactQueryParams.
+		RedactQueryParams []string
+		// RedactJSONFields are the paths of json fields to redact in the bodies, such as "password" and "user.token",
+		// the path is applied to every element of arrays.
+		RedactJSONFields []string
+	}
+
+	// Recorder is the http.RoundTripper which records and replays the interactions,
+	// use it by httpclient.WithTransport.
+	Recorder interface {
+		http.RoundTripper
+		// Cassette returns the interactions recorded and loaded.
+		Cassette() *Cassette
+		// Save writes the cassette file if there are new interactions recorded.
+		Save() error
+	}
+
+	defaultRecorder struct {
+		params   RecorderParams
+		redactor httpclient.Redactor
+
+		mu       sync.Mutex
+		cassette *Cassette
+		used     []bool
+		dirty    bool
+	}
+)
+
+// NewRecorder creates the Recorder, the cassette is loaded unless the mode is ModeRecord.
+func NewRecorder(params RecorderParams) (Recorder, error) { //nolint:gocritic
+	if params.Transport == nil {
+		params.Transport = http.DefaultTransport
+	}
+	if params.Matcher == nil {
+		params.Matcher = DefaultMatcher
+	}
+
+	rec := &defaultRecorder{
+		params: params,
+		redactor: httpclient.NewRedactor(httpclient.RedactorParams{
+			Headers:     params.RedactHeaders,
+			QueryParams: params.RedactQueryParams,
+			JSONFields:  params.RedactJSONFields,
+		}),
+		cassette: &Cassette{},
+	}
+
+	if params.Mode != ModeRecord {
+		data, err := os.ReadFile(params.Path)
+		switch {
+		case err == nil:
+			if err = json.Unmarshal(data, rec.cassette); err != nil {
+				return nil, errors.Wrapf(err, "decode cassette %s", params.Path)
+			}
+		case params.Mode == ModeReplay || !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+	rec.used = make([]bool, len(rec.cassette.Interactions))
+	return rec, nil
+}
+
+// Record creates the Recorder which is saved when the test finishes.
+// The mode is ModeRecord if tests run with -httpclienttest.record.
+func Record(t testing.TB, params RecorderParams) Recorder { //nolint:gocritic
+	t.Helper()
+
+	if *record {
+		params.Mode = ModeRecord
+	}
+	rec, err := NewRecorder(params)
+	if err != nil {
+		t.Fatalf("httpclienttest: %s, run tests with -httpclienttest.record to record it", err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Save(); err != nil {
+			t.Errorf("httpclienttest: save cassette: %s", err)
+		}
+	})
+	return rec
+}
+
+// DefaultMatcher matches the method, path, query and body, the json bodies are compared by values.
+func DefaultMatcher(r, recorded *RecordedRequest) bool {
+	if r.Method != recorded.Method {
+		return false
+	}
+
+	u1, err1 := url.Parse(r.URL)
+	u2, err2 := url.Parse(recorded.URL)
+	if err1 != nil || err2 != nil {
+		return r.URL == recorded.URL
+	}
+	if u1.Path != u2.Path || !reflect.DeepEqual(u1.Query(), u2.Query()) {
+		return false
+	}
+
+	if r.Body == recorded.Body && r.BodyEncoding == recorded.BodyEncoding {
+		return true
+	}
+	if r.BodyEncoding != "" || recorded.BodyEncoding != "" {
+		return false
+	}
+	var v1, v2 interface{}
+	if json.Unmarshal([]byte(r.Body), &v1) != nil || json.Unmarshal([]byte(recorded.Body), &v2) != nil {
+		return false
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+func (rec *defaultRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recordedRequest := rec.recordRequest(r, body)
+
+	if rec.params.Mode != ModeRecord {
+		if interaction := rec.match(recordedRequest); interaction != nil {
+			return replayResponse(r, interaction.Response)
+		}
+		if rec.params.Mode == ModeReplay && !rec.params.Passthrough {
+			return nil, errors.Wrapf(ErrInteractionNotFound, "%s %s", recordedRequest.Method, recordedRequest.URL)
+		}
+	}
+
+	resp, err := rec.params.Transport.RoundTrip(r)
+	if err != nil || rec.params.Mode == ModeReplay {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recordedResponse := &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     rec.redactor.RedactHeader(resp.Header),
+	}
+	recordedResponse.Body, recordedResponse.BodyEncoding = encodeBody(rec.redactor.RedactBody(respBody))
+
+	rec.mu.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, &Interaction{
+		Request:  recordedRequest,
+		Response: recordedResponse,
+	})
+	rec.used = append(rec.used, true)
+	rec.dirty = true
+	rec.mu.Unlock()
+	return resp, nil
+}
+
+func (rec *defaultRecorder) Cassette() *Cassette {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return &Cassette{
+		Interactions: append([]*Interaction(nil), rec.cassette.Interactions...),
+	}
+}
+
+func (rec *defaultRecorder) Save() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if !rec.dirty && rec.params.Mode != ModeRecord {
+		return nil
+	}
+	data, err := json.MarshalIndent(rec.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(rec.params.Path), 0o755); err != nil { //nolint:gomnd
+		return err
+	}
+	if err = os.WriteFile(rec.params.Path, append(data, '\n'), 0o600); err != nil { //nolint:gomnd
+		return err
+	}
+	rec.dirty = false
+	return nil
+}
+
+// match returns the first unused interaction matched, the last one matched is reused if all of them are used.
+func (rec *defaultRecorder) match(r *RecordedRequest) *Interaction {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	lastMatched := -1
+	for i, interaction := range rec.cassette.Interactions {
+		if !rec.params.Matcher(r, interaction.Request) {
+			continue
+		}
+		if !rec.used[i] {
+			rec.used[i] = true
+			return interaction
+		}
+		lastMatched = i
+	}
+	if lastMatched < 0 {
+		return nil
+	}
+	return rec.cassette.Interactions[lastMatched]
+}
+
+func (rec *defaultRecorder) recordRequest(r *http.Request, body []byte) *RecordedRequest {
+	recorded := &RecordedRequest{
+		Method: r.Method,
+		URL:    rec.redactor.RedactURL(r.URL).String(),
+		Header: rec.redactor.RedactHeader(r.Header),
+	}
+	recorded.Body, recorded.BodyEncoding = encodeBody(rec.redactor.RedactBody(body))
+	return recorded
+}
+
+func replayResponse(r *http.Request, recorded *RecordedResponse) (*http.Response, error) {
+	body, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode the recorded response of %s %s", r.Method, r.URL)
+	}
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
+
+// encodeBody returns the body as is if it's valid UTF-8, otherwise it's base64 encoded.
+func encodeBody(body []byte) (encoded, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), BodyEncodingBase64
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case BodyEncodingBase64:
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, errors.Errorf("unknown body encoding %q", encoding)
+	}
+}
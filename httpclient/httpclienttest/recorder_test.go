@@ -0,0 +1,266 @@
+package httpclienttest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/httpclient"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestRecorder(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=hidden")
+		if r.URL.Path == "/users/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":1,"name":"n","token":"hidden"}`)
+		if n > 2 {
+			t.Errorf("unexpected call %s %s", r.Method, r.URL)
+		}
+	}))
+	addr := testServer.URL
+
+	cassettePath := filepath.Join(t.TempDir(), "testdata", "users.json")
+	params := RecorderParams{
+		Path:             cassettePath,
+		Mode:             ModeRecord,
+		RedactJSONFields: []string{"password", "token"},
+	}
+
+	// record
+	rec, err := NewRecorder(params)
+	ast.NoError(err)
+	c := httpclient.NewObjectClient(addr, httpclient.WithTransport(rec))
+
+	var user testUser
+	ast.NoError(c.Get("/users/1", &user, httpclient.WithQueryParam("access_token", "hidden"), httpclient.WithAuthToken("hidden")))
+	ast.Equal(testUser{ID: 1, Name: "n"}, user)
+	ast.NoError(c.Post("/users", map[string]interface{}{"name": "n", "age": 1, "password": "hidden"}, &user))
+	ast.Error(c.Get("/users/2", &user))
+	ast.Len(rec.Cassette().Interactions, 3)
+	ast.NoError(rec.Save())
+
+	data, err := os.ReadFile(cassettePath)
+	ast.NoError(err)
+	ast.NotContains(string(data), "hidden")
+	ast.Contains(string(data), "[REDACTED]")
+
+	testServer.Close()
+
+	// replay
+	params.Mode = ModeReplay
+	rec, err = NewRecorder(params)
+	ast.NoError(err)
+	c = httpclient.NewObjectClient(addr, httpclient.WithTransport(rec))
+
+	user = testUser{}
+	ast.NoError(c.Get("/users/1", &user, httpclient.WithQueryParam("access_token", "other")))
+	ast.Equal(testUser{ID: 1, Name: "n"}, user)
+	user = testUser{}
+	ast.NoError(c.Post("/users", map[string]interface{}{"password": "other", "age": 1, "name": "n"}, &user))
+	ast.Equal(testUser{ID: 1, Name: "n"}, user)
+	err = c.Get("/users/2", &user)
+	if e, ok := httpclient.AsResponseError(err); ast.True(ok) {
+		ast.True(e.IsStatusCode(http.StatusNotFound))
+	}
+
+	// reuse the last matched
+	ast.NoError(c.Get("/users/1", &user, httpclient.WithQueryParam("access_token", "other")))
+
+	// unmatched
+	err = c.Get("/users/1", &user, httpclient.WithQueryParam("k", "v"))
+	ast.True(errors.Is(err, ErrInteractionNotFound))
+	err = c.Post("/users", map[string]interface{}{"name": "n2"}, &user)
+	ast.True(errors.Is(err, ErrInteractionNotFound))
+	ast.Error(c.Put("/users/1", nil, &user))
+
+	ast.NoError(rec.Save())
+	ast.EqualValues(3, atomic.LoadInt32(&calls))
+}
+
+func TestRecorderReplayOrRecord(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer testServer.Close()
+
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+	params := RecorderParams{Path: cassettePath, Mode: ModeReplayOrRecord}
+
+	rec, err := NewRecorder(params)
+	ast.NoError(err)
+	c := httpclient.NewBytesClient(testServer.URL, httpclient.WithTransport(rec))
+	data, err := c.Get("/a")
+	ast.NoError(err)
+	ast.Equal("/a", string(data))
+	ast.NoError(rec.Save())
+
+	rec, err = NewRecorder(params)
+	ast.NoError(err)
+	c = httpclient.NewBytesClient(testServer.URL, httpclient.WithTransport(rec))
+	data, err = c.Get("/a")
+	ast.NoError(err)
+	ast.Equal("/a", string(data))
+	data, err = c.Get("/b")
+	ast.NoError(err)
+	ast.Equal("/b", string(data))
+	ast.NoError(rec.Save())
+	ast.EqualValues(2, atomic.LoadInt32(&calls))
+	ast.Len(rec.Cassette().Interactions, 2)
+
+	// the unmatched request fails in replay mode
+	params.Mode = ModeReplay
+	rec, err = NewRecorder(params)
+	ast.NoError(err)
+	c = httpclient.NewBytesClient(testServer.URL, httpclient.WithTransport(rec))
+	_, err = c.Get("/c")
+	ast.True(errors.Is(err, ErrInteractionNotFound))
+
+	// passthrough, the unmatched request is sent without recording
+	params.Passthrough = true
+	rec, err = NewRecorder(params)
+	ast.NoError(err)
+	c = httpclient.NewBytesClient(testServer.URL, httpclient.WithTransport(rec))
+	data, err = c.Get("/c")
+	ast.NoError(err)
+	ast.Equal("/c", string(data))
+	ast.Len(rec.Cassette().Interactions, 2)
+	ast.EqualValues(3, atomic.LoadInt32(&calls))
+
+	// the cassette must exist to replay
+	_, err = NewRecorder(RecorderParams{Path: filepath.Join(t.TempDir(), "not-exist.json")})
+	ast.True(os.IsNotExist(err))
+}
+
+func TestRecorderBinaryBody(t *testing.T) {
+	ast := assert.New(t)
+
+	binary := []byte{0xff, 0xfe, 0x00, 0x01}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append(body, binary...))
+	}))
+	addr := testServer.URL
+
+	params := RecorderParams{Path: filepath.Join(t.TempDir(), "cassette.json"), Mode: ModeRecord}
+	rec, err := NewRecorder(params)
+	ast.NoError(err)
+	c := httpclient.NewBytesClient(addr, httpclient.WithTransport(rec))
+	data, err := c.Post("/", binary)
+	ast.NoError(err)
+	ast.Equal(append(binary, binary...), data)
+	ast.NoError(rec.Save())
+	testServer.Close()
+
+	if interactions := rec.Cassette().Interactions; ast.Len(interactions, 1) {
+		ast.Equal(BodyEncodingBase64, interactions[0].Request.BodyEncoding)
+		ast.Equal("//4AAQ==", interactions[0].Request.Body)
+		ast.Equal(BodyEncodingBase64, interactions[0].Response.BodyEncoding)
+	}
+
+	params.Mode = ModeReplay
+	rec, err = NewRecorder(params)
+	ast.NoError(err)
+	c = httpclient.NewBytesClient(addr, httpclient.WithTransport(rec))
+	data, err = c.Post("/", binary)
+	ast.NoError(err)
+	ast.Equal(append(binary, binary...), data)
+}
+
+func TestRecord(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer testServer.Close()
+
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+	t.Run("record", func(t *testing.T) {
+		rec := Record(t, RecorderParams{Path: cassettePath, Mode: ModeReplayOrRecord})
+		_, err := httpclient.NewBytesClient(testServer.URL, httpclient.WithTransport(rec)).Get("/")
+		ast.NoError(err)
+	})
+	_, err := os.Stat(cassettePath)
+	ast.NoError(err)
+}
+
+func TestDefaultMatcher(t *testing.T) {
+	ast := assert.New(t)
+
+	tests := []struct {
+		r, recorded *RecordedRequest
+		expected    bool
+	}{
+		{
+			r:        &RecordedRequest{Method: "GET", URL: "http://a/p?x=1&y=2"},
+			recorded: &RecordedRequest{Method: "GET", URL: "http://b/p?y=2&x=1"},
+			expected: true,
+		},
+		{
+			r:        &RecordedRequest{Method: "GET", URL: "http://a/p"},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p"},
+		},
+		{
+			r:        &RecordedRequest{Method: "GET", URL: "http://a/p?x=1"},
+			recorded: &RecordedRequest{Method: "GET", URL: "http://a/p?x=2"},
+		},
+		{
+			r:        &RecordedRequest{Method: "GET", URL: "http://a/p1"},
+			recorded: &RecordedRequest{Method: "GET", URL: "http://a/p2"},
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: `{"a":1,"b":[1,2]}`},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: `{"b":[1, 2],"a":1}`},
+			expected: true,
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: `{"a":1}`},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: `{"a":2}`},
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "text"},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "text"},
+			expected: true,
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "text1"},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "text2"},
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "//4=", BodyEncoding: BodyEncodingBase64},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "//4=", BodyEncoding: BodyEncodingBase64},
+			expected: true,
+		},
+		{
+			r:        &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "1", BodyEncoding: BodyEncodingBase64},
+			recorded: &RecordedRequest{Method: "POST", URL: "http://a/p", Body: "1"},
+		},
+	}
+	for i, test := range tests {
+		ast.Equal(test.expected, DefaultMatcher(test.r, test.recorded), "%d", i)
+	}
+}
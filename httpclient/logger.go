@@ -0,0 +1,201 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const DefaultLoggerMaxBodySize = 4096
+
+type (
+	LoggerParams struct {
+		// ContextPrintf writes the logs, default is log.Printf.
+		ContextPrintf func(ctx context.Context, format string, a ...interface{})
+		// LogHeaders logs the request and response headers.
+		LogHeaders bool
+		// LogBodies logs the request and response bodies, the streaming bodies are not logged.
+		LogBodies bool
+		// MaxBodySize truncates the logged bodies, default is DefaultLoggerMaxBodySize.
+		MaxBodySize int
+		// RedactHeaders are the headers to redact, default is DefaultRedactHeaders.
+		RedactHeaders []string
+		// RedactQueryParams are the query params to redact, default is DefaultRedactQueryParams.
+		RedactQueryParams []string
+		// RedactJSONFields are the paths of json fields to redact in bodies, such as "password" and "user.token",
+		// the path is applied to every element of arrays.
+		RedactJSONFields []string
+		// Curl logs the equivalent curl command of the request.
+		Curl bool
+	}
+
+	requestLogger struct {
+		params   LoggerParams
+		redactor Redactor
+	}
+)
+
+// WithLogger logs the method, url, status, latency and attempt of each request, and optionally the headers and bodies.
+// The sensitive headers, query params and json fields are redacted.
+func WithLogger(params LoggerParams) RequestOption { //nolint:gocritic
+	if params.ContextPrintf == nil {
+		params.ContextPrintf = func(_ context.Context, format string, a ...interface{}) {
+			log.Printf(format, a...)
+		}
+	}
+	if params.MaxBodySize <= 0 {
+		params.MaxBodySize = DefaultLoggerMaxBodySize
+	}
+
+	l := &requestLogger{
+		params: params,
+		redactor: NewRedactor(RedactorParams{
+			Headers:     params.RedactHeaders,
+			QueryParams: params.RedactQueryParams,
+			JSONFields:  params.RedactJSONFields,
+		}),
+	}
+
+	return func(o *requestOptions) {
+		o.linkAfterRequestHook(l.log)
+	}
+}
+
+func (l *requestLogger) log(r *resty.Request, resp *resty.Response, err error) {
+	var sb strings.Builder
+
+	requestURL := l.requestURL(r)
+	fmt.Fprintf(&sb, "%s %s", r.Method, requestURL)
+	if resp != nil && resp.RawResponse != nil {
+		fmt.Fprintf(&sb, " %d %s", resp.StatusCode(), resp.Time())
+	} else if !r.Time.IsZero() {
+		fmt.Fprintf(&sb, " %s", time.Since(r.Time))
+	}
+	fmt.Fprintf(&sb, " attempt=%d", r.Attempt)
+	if err != nil {
+		fmt.Fprintf(&sb, " error=%q", err.Error())
+	}
+
+	requestHeader := l.requestHeader(r)
+	requestBody, requestBodyOK := l.requestBody(r)
+	if l.params.LogHeaders {
+		fmt.Fprintf(&sb, "\nrequest headers: %s", l.formatHeader(requestHeader))
+		if resp != nil && resp.RawResponse != nil {
+			fmt.Fprintf(&sb, "\nresponse headers: %s", l.formatHeader(resp.Header()))
+		}
+	}
+	if l.params.LogBodies {
+		if requestBodyOK {
+			fmt.Fprintf(&sb, "\nrequest body: %s", l.truncate(requestBody))
+		}
+		if resp != nil && resp.Body() != nil {
+			fmt.Fprintf(&sb, "\nresponse body: %s", l.truncate(l.redactor.RedactBody(resp.Body())))
+		}
+	}
+	if l.params.Curl {
+		fmt.Fprintf(&sb, "\ncurl: %s", l.curl(r.Method, requestURL, requestHeader, requestBody, requestBodyOK))
+	}
+
+	l.params.ContextPrintf(r.Context(), "%s", sb.String())
+}
+
+func (l *requestLogger) requestURL(r *resty.Request) string {
+	rawURL := r.URL
+	if r.RawRequest != nil && r.RawRequest.URL != nil {
+		rawURL = r.RawRequest.URL.String()
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return l.redactor.RedactURL(u).String()
+}
+
+// requestHeader returns the headers which are sent, including the ones of the client and the credentials.
+func (*requestLogger) requestHeader(r *resty.Request) http.Header {
+	if r.RawRequest != nil {
+		return r.RawRequest.Header
+	}
+	return r.Header
+}
+
+// requestBody returns the redacted request body, false if it's not available to log.
+func (l *requestLogger) requestBody(r *resty.Request) ([]byte, bool) {
+	switch body := r.Body.(type) {
+	case nil:
+		return nil, false
+	case []byte:
+		return l.redactor.RedactBody(body), true
+	case string:
+		return l.redactor.RedactBody([]byte(body)), true
+	case io.Reader:
+		return nil, false
+	default:
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return nil, false
+		}
+		return l.redactor.RedactBody(bs), true
+	}
+}
+
+func (l *requestLogger) formatHeader(header http.Header) string {
+	header = l.redactor.RedactHeader(header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, fmt.Sprintf("%s: %s", k, strings.Join(header[k], ", ")))
+	}
+	return strings.Join(items, "; ")
+}
+
+func (l *requestLogger) truncate(body []byte) string {
+	if len(body) <= l.params.MaxBodySize {
+		return string(body)
+	}
+	return fmt.Sprintf("%s...(%d bytes truncated)", body[:l.params.MaxBodySize], len(body)-l.params.MaxBodySize)
+}
+
+func (l *requestLogger) curl(method, requestURL string, header http.Header, body []byte, bodyOK bool) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X ")
+	sb.WriteString(method)
+
+	header = l.redactor.RedactHeader(header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(" -H ")
+		sb.WriteString(shellQuote(k + ": " + strings.Join(header[k], ", ")))
+	}
+
+	if bodyOK {
+		sb.WriteString(" --data-binary ")
+		sb.WriteString(shellQuote(string(body)))
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(shellQuote(requestURL))
+	return sb.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=hidden")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_, _ = io.WriteString(w, `{"user":{"name":"n","token":"hidden"},"items":[{"secret":"hidden"},{"secret":"hidden"}]}`)
+	}))
+	defer testServer.Close()
+
+	var logs []string
+	printf := func(_ context.Context, format string, a ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, a...))
+	}
+
+	c := NewClient(testServer.URL, WithLogger(LoggerParams{ContextPrintf: printf}))
+	_, err := c.Get("/path", WithQueryParam("access_token", "secret"), WithQueryParam("k", "v"))
+	ast.NoError(err)
+	if ast.Len(logs, 1) {
+		ast.Regexp(`^GET http://127\.0\.0\.1:\d+/path\?access_token=%5BREDACTED%5D&k=v 200 \S+ attempt=1$`, logs[0])
+	}
+
+	logs = nil
+	c = NewClient(testServer.URL, WithLogger(LoggerParams{
+		ContextPrintf:    printf,
+		LogHeaders:       true,
+		LogBodies:        true,
+		MaxBodySize:      80,
+		RedactJSONFields: []string{"password", "user.token", "items.secret"},
+	}))
+	_, err = c.Post("/path", map[string]string{"name": "n", "password": "hidden"},
+		WithAuthToken("hidden"), WithHeader("Cookie", "session=hidden"))
+	ast.NoError(err)
+	if ast.Len(logs, 1) {
+		ast.NotContains(logs[0], "hidden")
+		ast.Contains(logs[0], "\nrequest headers: ")
+		ast.Contains(logs[0], "Authorization: [REDACTED]")
+		ast.Contains(logs[0], "Cookie: [REDACTED]")
+		ast.Contains(logs[0], "\nresponse headers: ")
+		ast.Contains(logs[0], "Set-Cookie: [REDACTED]")
+		ast.Contains(logs[0], `request body: {"name":"n","password":"[REDACTED]"}`)
+		ast.Contains(logs[0], `response body: {"items":[{"secret":"[REDACTED]"},{"secret":"[REDACTED]"}],"user":`)
+		ast.Contains(logs[0], "bytes truncated)")
+	}
+
+	logs = nil
+	c = NewClient(testServer.URL, WithLogger(LoggerParams{ContextPrintf: printf, Curl: true}))
+	_, err = c.Put("/path", "it's body", WithHeader("X-Key", "v"), WithQueryParam("access_token", "secret"))
+	ast.NoError(err)
+	if ast.Len(logs, 1) {
+		ast.Regexp(`\ncurl: curl -X PUT .*-H 'X-Key: v' --data-binary 'it'\\''s body' 'http://127\.0\.0\.1:\d+/path\?access_token=%5BREDACTED%5D'$`, logs[0])
+	}
+
+	// the headers of the client, content type and credentials
+	logs = nil
+	c = NewClient(testServer.URL, WithLogger(LoggerParams{ContextPrintf: printf, Curl: true}),
+		WithNewClientHook(func(c *resty.Client) {
+			c.SetHeader("X-Client", "c")
+		}))
+	_, err = c.Post("/path", map[string]string{"k": "v"}, WithBasicAuth("user", "hidden"))
+	ast.NoError(err)
+	if ast.Len(logs, 1) {
+		ast.NotContains(logs[0], "hidden")
+		ast.Contains(logs[0], "-H 'Authorization: [REDACTED]'")
+		ast.Contains(logs[0], "-H 'Content-Type: application/json")
+		ast.Contains(logs[0], "-H 'X-Client: c'")
+	}
+
+	// the error and streaming body
+	logs = nil
+	c = NewClient("http://127.0.0.1:1", WithLogger(LoggerParams{ContextPrintf: printf, LogBodies: true}))
+	_, err = c.Post("/", strings.NewReader("stream"))
+	ast.Error(err)
+	if ast.Len(logs, 1) {
+		ast.Contains(logs[0], "POST http://127.0.0.1:1/")
+		ast.Contains(logs[0], "error=")
+		ast.NotContains(logs[0], "request body")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal(`'a'\''b'`, shellQuote(`a'b`))
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// OAuth2AuthStyleHeader sends the client credentials by HTTP Basic Authorization.
+	OAuth2AuthStyleHeader OAuth2AuthStyle = iota
+	// OAuth2AuthStyleParams sends the client credentials in the form body.
+	OAuth2AuthStyleParams
+)
+
+var ErrOAuth2EmptyToken = errors.New("oauth2: server response missing access_token")
+
+type (
+	OAuth2AuthStyle int
+
+	ClientCredentialsParams struct {
+		// TokenURL is the url of the token endpoint.
+		TokenURL     string
+		ClientID     string
+		ClientSecret string
+		Scopes       []string
+		// EndpointParams are the additional params sent to the token endpoint, such as audience.
+		EndpointParams url.Values
+		// AuthStyle is how the client credentials are sent, default is OAuth2AuthStyleHeader.
+		AuthStyle OAuth2AuthStyle
+		// EarlyRefresh refreshes the token before it's expired, default is DefaultTokenEarlyRefresh.
+		EarlyRefresh time.Duration
+		// Opts are the options of the client to request the token endpoint.
+		Opts []RequestOption
+	}
+
+	// OAuth2ErrorObject is the error response of the token endpoint, see RFC 6749 section 5.2.
+	OAuth2ErrorObject struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+		ErrorURI         string `json:"error_uri,omitempty"`
+	}
+
+	oauth2TokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+)
+
+// NewClientCredentialsTokenSource creates the RefreshableTokenSource by the OAuth2 client credentials grant,
+// see RFC 6749 section 4.4. The error response of the token endpoint is a ResponseError with OAuth2ErrorObject.
+func NewClientCredentialsTokenSource(params ClientCredentialsParams) RefreshableTokenSource { //nolint:gocritic
+	opts := make([]RequestOption, 0, len(params.Opts)+2)
+	opts = append(opts, params.Opts...)
+	opts = append(opts, WithContentType(ContentTypeForm), WithErrorObject(&OAuth2ErrorObject{}))
+	client := NewObjectClient("", opts...)
+
+	return NewRefreshableTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		form := url.Values{}
+		for k, values := range params.EndpointParams {
+			form[k] = values
+		}
+		form.Set("grant_type", "client_credentials")
+		if len(params.Scopes) > 0 {
+			form.Set("scope", strings.Join(params.Scopes, " "))
+		}
+
+		reqOpts := []RequestOption{WithContext(ctx), WithHeader("Accept", ContentTypeJSON)}
+		if params.AuthStyle == OAuth2AuthStyleParams {
+			form.Set("client_id", params.ClientID)
+			if params.ClientSecret != "" {
+				form.Set("client_secret", params.ClientSecret)
+			}
+		} else {
+			reqOpts = append(reqOpts, WithBasicAuth(url.QueryEscape(params.ClientID), url.QueryEscape(params.ClientSecret)))
+		}
+
+		var resp oauth2TokenResponse
+		if err := client.Post(params.TokenURL, form, &resp, reqOpts...); err != nil {
+			return nil, err
+		}
+		if resp.AccessToken == "" {
+			return nil, ErrOAuth2EmptyToken
+		}
+
+		token := &Token{
+			AccessToken: resp.AccessToken,
+			TokenType:   resp.TokenType,
+		}
+		if strings.EqualFold(token.TokenType, "bearer") {
+			token.TokenType = "Bearer"
+		}
+		if resp.ExpiresIn > 0 {
+			token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+		}
+		return token, nil
+	}), params.EarlyRefresh)
+}
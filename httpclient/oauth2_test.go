@@ -0,0 +1,137 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenServer(t *testing.T, calls *int32, expiresIn int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ast := assert.New(t)
+		ast.Equal(http.MethodPost, r.Method)
+		ast.Equal(ContentTypeForm, r.Header.Get("Content-Type"))
+		ast.NoError(r.ParseForm())
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if ok {
+			// the credentials are form-encoded before basic auth, see RFC 6749 section 2.3.1
+			clientID, _ = url.QueryUnescape(clientID)
+			clientSecret, _ = url.QueryUnescape(clientSecret)
+		} else {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		if r.PostForm.Get("grant_type") != "client_credentials" || clientID != "id" || clientSecret != "secret:1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+			return
+		}
+
+		n := atomic.AddInt32(calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("%s-%s-%d", r.PostForm.Get("scope"), r.PostForm.Get("audience"), n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+}
+
+func TestNewClientCredentialsTokenSource(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	tokenServer := newTestTokenServer(t, &calls, 3600)
+	defer tokenServer.Close()
+
+	for _, authStyle := range []OAuth2AuthStyle{OAuth2AuthStyleHeader, OAuth2AuthStyleParams} {
+		atomic.StoreInt32(&calls, 0)
+		ts := NewClientCredentialsTokenSource(ClientCredentialsParams{
+			TokenURL:       tokenServer.URL + "/token",
+			ClientID:       "id",
+			ClientSecret:   "secret:1",
+			Scopes:         []string{"read", "write"},
+			EndpointParams: url.Values{"audience": []string{"api"}},
+			AuthStyle:      authStyle,
+		})
+
+		token, err := ts.Token(context.Background())
+		ast.NoError(err)
+		ast.Equal("read write-api-1", token.AccessToken)
+		ast.Equal("Bearer", token.Type())
+		ast.WithinDuration(time.Now().Add(time.Hour), token.Expiry, time.Minute)
+
+		token, err = ts.Token(context.Background())
+		ast.NoError(err)
+		ast.Equal("read write-api-1", token.AccessToken)
+		ast.EqualValues(1, atomic.LoadInt32(&calls))
+	}
+
+	// error response
+	ts := NewClientCredentialsTokenSource(ClientCredentialsParams{
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "id",
+		ClientSecret: "wrong",
+	})
+	_, err := ts.Token(context.Background())
+	if e, ok := AsResponseError(err); ast.True(ok, "%v", err) {
+		ast.True(e.IsStatusCode(http.StatusUnauthorized))
+		if errObj, ok := GetErrorObject(err).(*OAuth2ErrorObject); ast.True(ok) {
+			ast.Equal("invalid_client", errObj.Error)
+			ast.Equal("bad credentials", errObj.ErrorDescription)
+		}
+	}
+
+	// empty token
+	emptyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"token_type":"bearer"}`)
+	}))
+	defer emptyServer.Close()
+	ts = NewClientCredentialsTokenSource(ClientCredentialsParams{TokenURL: emptyServer.URL})
+	_, err = ts.Token(context.Background())
+	ast.True(errors.Is(err, ErrOAuth2EmptyToken))
+}
+
+func TestClientCredentialsWithTokenSource(t *testing.T) {
+	ast := assert.New(t)
+
+	var calls int32
+	tokenServer := newTestTokenServer(t, &calls, 1)
+	defer tokenServer.Close()
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer testServer.Close()
+
+	ts := NewClientCredentialsTokenSource(ClientCredentialsParams{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "id",
+		ClientSecret: "secret:1",
+		EarlyRefresh: 500 * time.Millisecond,
+	})
+	c := NewBytesClient(testServer.URL, WithTokenSource(ts))
+
+	data, err := c.Get("/")
+	ast.NoError(err)
+	ast.Equal("Bearer --1", string(data))
+	data, err = c.Get("/")
+	ast.NoError(err)
+	ast.Equal("Bearer --1", string(data))
+
+	// refreshed before it's expired
+	time.Sleep(600 * time.Millisecond)
+	data, err = c.Get("/")
+	ast.NoError(err)
+	ast.Equal("Bearer --2", string(data))
+}
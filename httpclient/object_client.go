@@ -1,8 +1,7 @@
+//go:generate mockgen -package httpclient -destination object_client_mock.go -source object_client.go ObjectClient
 package httpclient
 
 import (
-	"encoding/json"
-
 	"github.com/go-resty/resty/v2"
 )
 
@@ -35,40 +34,40 @@ func NewObjectClientRaw(cli Client) ObjectClient {
 
 func (c *defaultObjectClient) Get(urlPath string, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Get(urlPath, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
 func (c *defaultObjectClient) Post(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Post(urlPath, body, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
 func (c *defaultObjectClient) Put(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Put(urlPath, body, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
 func (c *defaultObjectClient) Patch(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Patch(urlPath, body, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
 func (c *defaultObjectClient) Delete(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Delete(urlPath, body, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
 func (c *defaultObjectClient) Execute(method, urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
 	resp, err := c.client.Execute(method, urlPath, body, opts...)
-	return c.convertResponse(responseObj, resp, err)
+	return c.convertResponse(responseObj, resp, err, opts)
 }
 
-func (*defaultObjectClient) convertResponse(responseObj interface{}, resp *resty.Response, err error) error {
+func (c *defaultObjectClient) convertResponse(responseObj interface{}, resp *resty.Response, err error, opts []RequestOption) error {
 	if err != nil {
 		return err
 	}
 
-	if err = NewResponseErrorNotSuccess(resp); err != nil {
+	if err = newResponseErrorNotSuccess(resp, requestOptionsOf(c.client, opts...)); err != nil {
 		return err
 	}
 
@@ -76,8 +75,7 @@ func (*defaultObjectClient) convertResponse(responseObj interface{}, resp *resty
 		return nil
 	}
 
-	// Only support json response now
-	if err := json.Unmarshal(resp.Body(), responseObj); err != nil {
+	if err := decodeResponse(resp, responseObj); err != nil {
 		return NewResponseError(resp, err)
 	}
 	return nil
@@ -0,0 +1,148 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: object_client.go
+
+// Package httpclient is a generated GoMock package.
+package httpclient
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockObjectClient is a mock of ObjectClient interface.
+type MockObjectClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockObjectClientMockRecorder
+}
+
+// MockObjectClientMockRecorder is the mock recorder for MockObjectClient.
+type MockObjectClientMockRecorder struct {
+	mock *MockObjectClient
+}
+
+// NewMockObjectClient creates a new mock instance.
+func NewMockObjectClient(ctrl *gomock.Controller) *MockObjectClient {
+	mock := &MockObjectClient{ctrl: ctrl}
+	mock.recorder = &MockObjectClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObjectClient) EXPECT() *MockObjectClientMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockObjectClient) Delete(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockObjectClientMockRecorder) Delete(urlPath, body, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockObjectClient)(nil).Delete), varargs...)
+}
+
+// Execute mocks base method.
+func (m *MockObjectClient) Execute(method, urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{method, urlPath, body, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Execute", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Execute indicates an expected call of Execute.
+func (mr *MockObjectClientMockRecorder) Execute(method, urlPath, body, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{method, urlPath, body, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockObjectClient)(nil).Execute), varargs...)
+}
+
+// Get mocks base method.
+func (m *MockObjectClient) Get(urlPath string, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockObjectClientMockRecorder) Get(urlPath, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockObjectClient)(nil).Get), varargs...)
+}
+
+// Patch mocks base method.
+func (m *MockObjectClient) Patch(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Patch", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockObjectClientMockRecorder) Patch(urlPath, body, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockObjectClient)(nil).Patch), varargs...)
+}
+
+// Post mocks base method.
+func (m *MockObjectClient) Post(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Post", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockObjectClientMockRecorder) Post(urlPath, body, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockObjectClient)(nil).Post), varargs...)
+}
+
+// Put mocks base method.
+func (m *MockObjectClient) Put(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{urlPath, body, responseObj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Put", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockObjectClientMockRecorder) Put(urlPath, body, responseObj interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{urlPath, body, responseObj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockObjectClient)(nil).Put), varargs...)
+}
@@ -0,0 +1,233 @@
+package httpclient
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ http.RoundTripper = (*rateLimitTransport)(nil)
+	_ http.RoundTripper = (*maxInFlightTransport)(nil)
+
+	ErrRateLimited = errors.New("rate limited")
+	ErrMaxInFlight = errors.New("too many in-flight requests")
+)
+
+type (
+	RateLimitParams struct {
+		// Rate is the number of requests allowed per second, zero or negative disables the limit.
+		Rate float64
+		// Burst is the max number of requests allowed at once, default is the ceil of Rate.
+		Burst int
+		// FailFast fails the request with ErrRateLimited instead of waiting for the token.
+		FailFast bool
+		// GetKey returns the key of the limiter for the request, default is RateLimitKeyHost.
+		GetKey func(r *http.Request) string
+	}
+
+	MaxInFlightParams struct {
+		// Max is the max number of in-flight requests.
+		Max int
+		// FailFast fails the request with ErrMaxInFlight instead of waiting for the others.
+		FailFast bool
+		// GetKey returns the key of the limiter for the request, default is RateLimitKeyHost.
+		GetKey func(r *http.Request) string
+	}
+
+	rateLimitTransport struct {
+		params  RateLimitParams
+		next    http.RoundTripper
+		mu      *sync.Mutex
+		buckets map[string]*tokenBucket
+	}
+
+	tokenBucket struct {
+		tokens   float64
+		lastTime time.Time
+	}
+
+	maxInFlightTransport struct {
+		params MaxInFlightParams
+		next   http.RoundTripper
+		mu     *sync.Mutex
+		slots  map[string]chan struct{}
+	}
+
+	inFlightBody struct {
+		io.ReadCloser
+		once    sync.Once
+		release func()
+	}
+)
+
+// RateLimitKeyHost limits the requests per host.
+func RateLimitKeyHost(r *http.Request) string {
+	return r.URL.Host
+}
+
+// RateLimitKeyRoute limits the requests per method, host and path.
+func RateLimitKeyRoute(r *http.Request) string {
+	return r.Method + " " + r.URL.Host + r.URL.Path
+}
+
+// WithRateLimit limits the rate of requests by token bucket, the request waits for the token
+// until the context is done unless FailFast.
+// It wraps the transport, so it only takes effect for NewClient, and each retry attempt is counted.
+func WithRateLimit(params RateLimitParams) RequestOption {
+	if params.Rate <= 0 {
+		return func(*requestOptions) {} // the bucket is never refilled
+	}
+	if params.Burst <= 0 {
+		params.Burst = int(math.Ceil(params.Rate))
+		if params.Burst <= 0 {
+			params.Burst = 1
+		}
+	}
+	if params.GetKey == nil {
+		params.GetKey = RateLimitKeyHost
+	}
+
+	var (
+		mu      sync.Mutex
+		buckets = map[string]*tokenBucket{}
+	)
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &rateLimitTransport{params: params, next: rt, mu: &mu, buckets: buckets}
+		})
+	}
+}
+
+// WithMaxInFlight limits the number of in-flight requests, the request waits for the others
+// until the context is done unless FailFast.
+// The request is in-flight until the response body is closed.
+// It wraps the transport, so it only takes effect for NewClient.
+func WithMaxInFlight(params MaxInFlightParams) RequestOption {
+	if params.Max <= 0 {
+		params.Max = 1
+	}
+	if params.GetKey == nil {
+		params.GetKey = RateLimitKeyHost
+	}
+
+	var (
+		mu    sync.Mutex
+		slots = map[string]chan struct{}{}
+	)
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &maxInFlightTransport{params: params, next: rt, mu: &mu, slots: slots}
+		})
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := t.params.GetKey(r)
+	wait, ok := t.reserve(key)
+	if !ok {
+		closeRequestBody(r)
+		return nil, errors.Wrap(ErrRateLimited, key)
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			t.cancel(key)
+			closeRequestBody(r)
+			return nil, r.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	return t.next.RoundTrip(r)
+}
+
+// reserve takes a token, and returns the duration to wait for it.
+func (t *rateLimitTransport) reserve(key string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(t.params.Burst), lastTime: now}
+		t.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(t.params.Burst), b.tokens+now.Sub(b.lastTime).Seconds()*t.params.Rate)
+	b.lastTime = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if t.params.FailFast || t.params.Rate <= 0 {
+		return 0, false
+	}
+	b.tokens--
+	return time.Duration(-b.tokens / t.params.Rate * float64(time.Second)), true
+}
+
+// cancel gives back the token reserved.
+func (t *rateLimitTransport) cancel(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buckets[key].tokens++
+}
+
+func (t *maxInFlightTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := t.params.GetKey(r)
+
+	t.mu.Lock()
+	slot, ok := t.slots[key]
+	if !ok {
+		slot = make(chan struct{}, t.params.Max)
+		t.slots[key] = slot
+	}
+	t.mu.Unlock()
+
+	if t.params.FailFast {
+		select {
+		case slot <- struct{}{}:
+		default:
+			closeRequestBody(r)
+			return nil, errors.Wrap(ErrMaxInFlight, key)
+		}
+	} else {
+		select {
+		case slot <- struct{}{}:
+		case <-r.Context().Done():
+			closeRequestBody(r)
+			return nil, r.Context().Err()
+		}
+	}
+
+	release := func() { <-slot }
+	resp, err := t.next.RoundTrip(r)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (b *inFlightBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// closeRequestBody closes the body of the request which is not sent, as the http.RoundTripper does.
+func closeRequestBody(r *http.Request) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+}
@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	ast := assert.New(t)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithRateLimit(RateLimitParams{Rate: 20, Burst: 2}))
+	startTime := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := c.Get("/")
+		ast.NoError(err)
+	}
+	ast.GreaterOrEqual(int64(time.Since(startTime)), int64(90*time.Millisecond))
+
+	c = NewClient(testServer.URL, WithRateLimit(RateLimitParams{Rate: 1}))
+	_, err := c.Get("/")
+	ast.NoError(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Get("/", WithContext(ctx))
+	ast.ErrorIs(err, context.DeadlineExceeded)
+
+	c = NewClient(testServer.URL, WithRateLimit(RateLimitParams{Rate: 1, FailFast: true}))
+	_, err = c.Get("/")
+	ast.NoError(err)
+	_, err = c.Get("/")
+	ast.ErrorIs(err, ErrRateLimited)
+
+	// disabled
+	c = NewClient(testServer.URL, WithRateLimit(RateLimitParams{Rate: 0, FailFast: true}))
+	for i := 0; i < 3; i++ {
+		_, err = c.Get("/")
+		ast.NoError(err)
+	}
+}
+
+func TestRateLimitTransportReserve(t *testing.T) {
+	ast := assert.New(t)
+
+	rt := &rateLimitTransport{
+		params:  RateLimitParams{Rate: 10, Burst: 1},
+		mu:      &sync.Mutex{},
+		buckets: map[string]*tokenBucket{},
+	}
+
+	wait, ok := rt.reserve("a")
+	ast.True(ok)
+	ast.Equal(time.Duration(0), wait)
+	wait, ok = rt.reserve("a")
+	ast.True(ok)
+	ast.InDelta(float64(100*time.Millisecond), float64(wait), float64(5*time.Millisecond))
+
+	// the other key has its own bucket
+	wait, ok = rt.reserve("b")
+	ast.True(ok)
+	ast.Equal(time.Duration(0), wait)
+
+	rt.cancel("a")
+	wait, _ = rt.reserve("a")
+	ast.InDelta(float64(100*time.Millisecond), float64(wait), float64(5*time.Millisecond))
+
+	rt.params.FailFast = true
+	_, ok = rt.reserve("b")
+	ast.False(ok)
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		inFlight int32
+		maxSeen  int32
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxSeen)
+			if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithMaxInFlight(MaxInFlightParams{Max: 2}))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get("/")
+			ast.NoError(err)
+		}()
+	}
+	wg.Wait()
+	ast.EqualValues(2, atomic.LoadInt32(&maxSeen))
+
+	c = NewClient(testServer.URL, WithMaxInFlight(MaxInFlightParams{Max: 1, FailFast: true}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := c.Get("/")
+		ast.NoError(err)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	_, err := c.Get("/")
+	ast.ErrorIs(err, ErrMaxInFlight)
+	<-done
+	_, err = c.Get("/")
+	ast.NoError(err)
+}
+
+func TestRateLimitCloseBody(t *testing.T) {
+	ast := assert.New(t)
+
+	var closed int32
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/", nil)
+		r.Body = &testCloseBody{closed: &closed}
+		return r
+	}
+
+	rt := &rateLimitTransport{
+		params:  RateLimitParams{Rate: 1, FailFast: true, GetKey: RateLimitKeyHost},
+		mu:      &sync.Mutex{},
+		buckets: map[string]*tokenBucket{"localhost": {lastTime: time.Now()}},
+	}
+	_, err := rt.RoundTrip(newRequest())
+	ast.ErrorIs(err, ErrRateLimited)
+	ast.EqualValues(1, atomic.LoadInt32(&closed))
+
+	slot := make(chan struct{}, 1)
+	slot <- struct{}{}
+	it := &maxInFlightTransport{
+		params: MaxInFlightParams{Max: 1, FailFast: true, GetKey: RateLimitKeyHost},
+		mu:     &sync.Mutex{},
+		slots:  map[string]chan struct{}{"localhost": slot},
+	}
+	_, err = it.RoundTrip(newRequest())
+	ast.ErrorIs(err, ErrMaxInFlight)
+	ast.EqualValues(2, atomic.LoadInt32(&closed))
+}
+
+type testCloseBody struct {
+	closed *int32
+}
+
+func (*testCloseBody) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (b *testCloseBody) Close() error {
+	atomic.AddInt32(b.closed, 1)
+	return nil
+}
+
+func TestRateLimitKey(t *testing.T) {
+	ast := assert.New(t)
+
+	r := &http.Request{Method: http.MethodGet, URL: &url.URL{Host: "localhost:8080", Path: "/api/v1"}}
+	ast.Equal("localhost:8080", RateLimitKeyHost(r))
+	ast.Equal("GET localhost:8080/api/v1", RateLimitKeyRoute(r))
+}
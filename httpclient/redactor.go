@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the values of the sensitive headers, query params and json fields.
+const Redacted = "[REDACTED]"
+
+var (
+	_ Redactor = (*defaultRedactor)(nil)
+
+	DefaultRedactHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	DefaultRedactQueryParams = []string{"access_token"}
+)
+
+type (
+	// Redactor redacts the sensitive data of the requests and responses before they're logged or recorded.
+	Redactor interface {
+		// RedactHeader returns the copy of header with the sensitive values redacted, nil if it's empty.
+		RedactHeader(header http.Header) http.Header
+		// RedactURL removes the password and redacts the sensitive query params of the url.
+		RedactURL(u *url.URL) *url.URL
+		// RedactBody redacts the json fields, the body which is not json is returned as is.
+		RedactBody(body []byte) []byte
+	}
+
+	RedactorParams struct {
+		// Headers are the headers to redact, default is DefaultRedactHeaders.
+		Headers []string
+		// QueryParams are the query params to redact, default is DefaultRedactQueryParams.
+		QueryParams []string
+		// JSONFields are the paths of json fields to redact in bodies, such as "password" and "user.token",
+		// the path is applied to every element of arrays.
+		JSONFields []string
+	}
+
+	defaultRedactor struct {
+		headers map[string]bool
+		params  map[string]bool
+		fields  [][]string
+	}
+)
+
+func NewRedactor(params RedactorParams) Redactor {
+	if params.Headers == nil {
+		params.Headers = DefaultRedactHeaders
+	}
+	if params.QueryParams == nil {
+		params.QueryParams = DefaultRedactQueryParams
+	}
+
+	r := &defaultRedactor{
+		headers: map[string]bool{},
+		params:  map[string]bool{},
+	}
+	for _, h := range params.Headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, p := range params.QueryParams {
+		r.params[p] = true
+	}
+	for _, f := range params.JSONFields {
+		r.fields = append(r.fields, strings.Split(f, "."))
+	}
+	return r
+}
+
+func (r *defaultRedactor) RedactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	h := header.Clone()
+	for k := range h {
+		if r.headers[http.CanonicalHeaderKey(k)] {
+			h[k] = []string{Redacted}
+		}
+	}
+	return h
+}
+
+func (r *defaultRedactor) RedactURL(u *url.URL) *url.URL {
+	redactedURL := *u
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redactedURL.User.Username())
+	}
+	query := redactedURL.Query()
+	redacted := false
+	for k := range query {
+		if r.params[k] {
+			query.Set(k, Redacted)
+			redacted = true
+		}
+	}
+	if redacted {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return &redactedURL
+}
+
+func (r *defaultRedactor) RedactBody(body []byte) []byte {
+	if len(r.fields) == 0 || len(body) == 0 {
+		return body
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	for _, path := range r.fields {
+		redactJSONField(v, path)
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return bs
+}
+
+func redactJSONField(v interface{}, path []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = Redacted
+			return
+		}
+		redactJSONField(child, path[1:])
+	case []interface{}:
+		for _, item := range v {
+			redactJSONField(item, path)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	DefaultRetryMaxAttempts     = 3
+	DefaultRetryInitialInterval = 100 * time.Millisecond
+	DefaultRetryMaxInterval     = 10 * time.Second
+	DefaultRetryMultiplier      = 2
+	DefaultRetryJitter          = 0.2
+)
+
+type (
+	// RetryPolicy is the policy to retry the requests.
+	RetryPolicy struct {
+		// MaxAttempts is the max number of attempts including the first one, default is DefaultRetryMaxAttempts.
+		MaxAttempts int
+		// InitialInterval is the interval before the first retry, default is DefaultRetryInitialInterval.
+		InitialInterval time.Duration
+		// MaxInterval limits the interval calculated by backoff, default is DefaultRetryMaxInterval.
+		// The retries stop if the Retry-After header exceeds it.
+		MaxInterval time.Duration
+		// Multiplier is the factor of interval for each retry, default is DefaultRetryMultiplier.
+		Multiplier float64
+		// Jitter randomizes the interval in [interval*(1-Jitter), interval*(1+Jitter)], default is DefaultRetryJitter.
+		// Negative value disables the jitter.
+		Jitter float64
+		// MaxElapsedTime stops the retries once the elapsed time exceeds it, default is 0 means no limit.
+		MaxElapsedTime time.Duration
+		// RetryNonIdempotent allows to retry the non-idempotent methods, such as POST and PATCH.
+		RetryNonIdempotent bool
+		// ShouldRetry reports whether to retry, default is DefaultShouldRetry.
+		ShouldRetry func(resp *resty.Response, err error) bool
+	}
+)
+
+// WithRetry retries the requests according to the policy.
+// The Retry-After header is honored, the body is rewound if it's an io.Seeker,
+// and the requests with the other io.Reader body are never retried.
+// The attempt count is reported by resty.Request.Attempt in afterRequestHook.
+func WithRetry(policy RetryPolicy) RequestOption { //nolint:gocritic
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = DefaultRetryInitialInterval
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = DefaultRetryMaxInterval
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DefaultRetryMultiplier
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = DefaultRetryJitter
+	} else if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	if policy.ShouldRetry == nil {
+		policy.ShouldRetry = DefaultShouldRetry
+	}
+	return func(o *requestOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// DefaultShouldRetry retries on the connection errors, 429 and 5xx except 501.
+// The context errors and CircuitOpenError are not retried.
+func DefaultShouldRetry(resp *resty.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !IsCircuitOpenError(err)
+	}
+	if resp == nil {
+		return false
+	}
+	statusCode := resp.StatusCode()
+	return statusCode == http.StatusTooManyRequests ||
+		(statusCode >= http.StatusInternalServerError && statusCode != http.StatusNotImplemented)
+}
+
+// IsIdempotentMethod reports whether the http method is idempotent.
+func IsIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (c *defaultClient) executeWithRetry(o *requestOptions, method, urlPath string) (*resty.Request, *resty.Response, error) {
+	var (
+		policy     = o.retryPolicy
+		startTime  = time.Now()
+		bodyOffset int64
+	)
+
+	for attempt := 1; ; attempt++ {
+		r, resp, err := c.executeAttempt(o, method, urlPath, func(r *resty.Request) error {
+			if s, ok := r.Body.(io.Seeker); ok {
+				if attempt == 1 {
+					offset, err := s.Seek(0, io.SeekCurrent)
+					bodyOffset = offset
+					return err
+				}
+				_, err := s.Seek(bodyOffset, io.SeekStart)
+				return err
+			}
+			return nil
+		})
+		r.Attempt = attempt
+
+		if attempt >= policy.MaxAttempts ||
+			(!policy.RetryNonIdempotent && !IsIdempotentMethod(method)) ||
+			!isBodyRewindable(r.Body) ||
+			!policy.ShouldRetry(resp, err) {
+			return r, resp, err
+		}
+
+		interval := policy.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp); ok {
+			if retryAfter > policy.MaxInterval {
+				return r, resp, err // the server asks to wait longer than the policy allows
+			}
+			interval = retryAfter
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(startTime)+interval > policy.MaxElapsedTime {
+			return r, resp, err
+		}
+
+		if resp != nil && resp.RawResponse != nil {
+			_ = resp.RawBody().Close() // the body is not read if the response is not parsed
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return r, nil, r.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the interval before the next attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	interval := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt-1))
+	if interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		interval *= 1 - p.Jitter + 2*p.Jitter*rand.Float64() //nolint:gosec
+	}
+	return time.Duration(interval)
+}
+
+// parseRetryAfter parses the Retry-After header in seconds or http date.
+func parseRetryAfter(resp *resty.Response) (time.Duration, bool) {
+	if resp == nil || resp.RawResponse == nil {
+		return 0, false
+	}
+	v := resp.Header().Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// isBodyRewindable reports whether the body can be sent again.
+func isBodyRewindable(body interface{}) bool {
+	if body == nil {
+		return true
+	}
+	if b, ok := body.(*uploadBody); ok {
+		return b.rewindable
+	}
+	if _, ok := body.(io.Reader); ok {
+		_, ok = body.(io.Seeker)
+		return ok
+	}
+	return true
+}
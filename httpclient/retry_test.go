@@ -0,0 +1,211 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		requests int32
+		failures int32
+		bodies   = make(chan string, 10)
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			if strings.HasPrefix(r.URL.Path, "/retry-after") {
+				retryAfter := "0"
+				if r.URL.Path == "/retry-after-long" {
+					retryAfter = "86400"
+				}
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	reset := func(n int32) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&failures, n)
+		for len(bodies) > 0 {
+			<-bodies
+		}
+	}
+
+	var attempt int
+	c := NewClient(testServer.URL, WithAfterRequestHook(func(r *resty.Request, _ *resty.Response, _ error) {
+		attempt = r.Attempt
+	}))
+	policy := RetryPolicy{InitialInterval: time.Millisecond, Jitter: -1}
+
+	reset(2)
+	resp, err := c.Get("/", WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal(http.StatusOK, resp.StatusCode())
+	ast.EqualValues(3, atomic.LoadInt32(&requests))
+	ast.Equal(3, attempt)
+
+	reset(3)
+	resp, err = c.Get("/", WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	ast.EqualValues(3, atomic.LoadInt32(&requests))
+
+	reset(1)
+	_, err = c.Get("/retry-after", WithRetry(RetryPolicy{InitialInterval: time.Hour}))
+	ast.NoError(err)
+	ast.EqualValues(2, atomic.LoadInt32(&requests))
+
+	// Retry-After exceeds MaxInterval
+	reset(1)
+	resp, err = c.Get("/retry-after-long", WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal(http.StatusTooManyRequests, resp.StatusCode())
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+
+	// non-idempotent
+	reset(1)
+	resp, err = c.Post("/", "body", WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+	ast.Equal(1, attempt)
+
+	reset(1)
+	policy.RetryNonIdempotent = true
+	_, err = c.Post("/", "body", WithRetry(policy))
+	ast.NoError(err)
+	ast.EqualValues(2, atomic.LoadInt32(&requests))
+
+	// seekable body is rewound
+	reset(1)
+	body := strings.NewReader("prefix-body")
+	_, _ = body.Seek(int64(len("prefix-")), io.SeekStart)
+	_, err = c.Put("/", body, WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal("body", <-bodies)
+	ast.Equal("body", <-bodies)
+
+	// non-seekable body is not retried
+	reset(1)
+	resp, err = c.Put("/", io.MultiReader(strings.NewReader("body")), WithRetry(policy))
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+
+	// custom ShouldRetry
+	reset(1)
+	_, err = c.Get("/", WithRetry(RetryPolicy{
+		InitialInterval: time.Millisecond,
+		ShouldRetry: func(*resty.Response, error) bool {
+			return false
+		},
+	}))
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+
+	// MaxElapsedTime
+	reset(1)
+	resp, err = c.Get("/", WithRetry(RetryPolicy{InitialInterval: time.Second, Jitter: -1, MaxElapsedTime: 100 * time.Millisecond}))
+	ast.NoError(err)
+	ast.Equal(http.StatusServiceUnavailable, resp.StatusCode())
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+
+	// context canceled while waiting
+	reset(3)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.Get("/", WithContext(ctx), WithRetry(RetryPolicy{InitialInterval: time.Hour}))
+	ast.ErrorIs(err, context.DeadlineExceeded)
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	ast := assert.New(t)
+
+	newResponse := func(statusCode int) *resty.Response {
+		return &resty.Response{RawResponse: &http.Response{StatusCode: statusCode}}
+	}
+
+	ast.True(DefaultShouldRetry(nil, errors.New("connection refused")))
+	ast.False(DefaultShouldRetry(nil, context.Canceled))
+	ast.False(DefaultShouldRetry(nil, context.DeadlineExceeded))
+	ast.False(DefaultShouldRetry(nil, NewCircuitOpenError("localhost")))
+	ast.False(DefaultShouldRetry(nil, nil))
+	ast.False(DefaultShouldRetry(newResponse(http.StatusOK), nil))
+	ast.False(DefaultShouldRetry(newResponse(http.StatusBadRequest), nil))
+	ast.True(DefaultShouldRetry(newResponse(http.StatusTooManyRequests), nil))
+	ast.True(DefaultShouldRetry(newResponse(http.StatusInternalServerError), nil))
+	ast.False(DefaultShouldRetry(newResponse(http.StatusNotImplemented), nil))
+	ast.True(DefaultShouldRetry(newResponse(http.StatusBadGateway), nil))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	ast := assert.New(t)
+
+	p := RetryPolicy{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 2}
+	ast.Equal(100*time.Millisecond, p.backoff(1))
+	ast.Equal(200*time.Millisecond, p.backoff(2))
+	ast.Equal(400*time.Millisecond, p.backoff(3))
+	ast.Equal(time.Second, p.backoff(5))
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.backoff(1)
+		ast.GreaterOrEqual(int64(d), int64(50*time.Millisecond))
+		ast.LessOrEqual(int64(d), int64(150*time.Millisecond))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	ast := assert.New(t)
+
+	newResponse := func(v string) *resty.Response {
+		header := http.Header{}
+		if v != "" {
+			header.Set("Retry-After", v)
+		}
+		return &resty.Response{RawResponse: &http.Response{Header: header}}
+	}
+
+	_, ok := parseRetryAfter(nil)
+	ast.False(ok)
+	_, ok = parseRetryAfter(newResponse(""))
+	ast.False(ok)
+	_, ok = parseRetryAfter(newResponse("invalid"))
+	ast.False(ok)
+	_, ok = parseRetryAfter(newResponse("-1"))
+	ast.False(ok)
+
+	d, ok := parseRetryAfter(newResponse("3"))
+	ast.True(ok)
+	ast.Equal(3*time.Second, d)
+
+	d, ok = parseRetryAfter(newResponse(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)))
+	ast.True(ok)
+	ast.Greater(int64(d), int64(59*time.Minute))
+
+	d, ok = parseRetryAfter(newResponse(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+	ast.True(ok)
+	ast.Equal(time.Duration(0), d)
+}
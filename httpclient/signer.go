@@ -0,0 +1,311 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignatureInHeader puts the signature, timestamp and key id in the headers.
+	SignatureInHeader SignatureLocation = iota
+	// SignatureInQuery puts the signature, timestamp and key id in the query params.
+	SignatureInQuery
+
+	DefaultSignatureMaxSkew = 5 * time.Minute
+)
+
+var (
+	_ Signer            = SignerFunc(nil)
+	_ HMACSigner        = (*defaultHMACSigner)(nil)
+	_ http.RoundTripper = (*signerTransport)(nil)
+
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrSignatureExpired = errors.New("signature expired")
+	// ErrSignatureStreamingBody is returned when signing the streaming body, such as the multipart and upload ones,
+	// which can not be hashed without reading it into memory, see HMACSignerParams.UnsignedBody.
+	ErrSignatureStreamingBody = errors.New("can not sign streaming body")
+)
+
+type (
+	// Signer signs the request before it's sent, such as setting the signature header.
+	Signer interface {
+		Sign(r *http.Request) error
+	}
+
+	SignerFunc func(r *http.Request) error
+
+	// HMACSigner signs the requests, and verifies the signed requests on the server side.
+	HMACSigner interface {
+		Signer
+		Verify(r *http.Request) error
+	}
+
+	SignatureLocation int
+
+	// CanonicalRequest is the parts of the request to be signed.
+	CanonicalRequest struct {
+		Method string
+		// Path is the escaped path of the url.
+		Path string
+		// Query is the query params without the signature.
+		Query  url.Values
+		Header http.Header
+		// BodyHash is the hex encoded sha256 of the body, it's empty if the body is unsigned.
+		BodyHash  string
+		Timestamp string
+		KeyID     string
+	}
+
+	// CanonicalizeFunc returns the string to sign of the request.
+	CanonicalizeFunc func(c *CanonicalRequest) string
+
+	HMACSignerParams struct {
+		Secret []byte
+		// KeyID is sent with the signature if it's not empty, so the server can find the secret.
+		KeyID string
+		// Location is where the signature is put, default is SignatureInHeader.
+		Location SignatureLocation
+		// SignatureName is the name of the header or query param of the signature,
+		// default is X-Signature in header and signature in query.
+		SignatureName string
+		// TimestampName is the name of the header or query param of the timestamp,
+		// default is X-Signature-Timestamp in header and timestamp in query.
+		TimestampName string
+		// KeyIDName is the name of the header or query param of the key id,
+		// default is X-Signature-Key-Id in header and key_id in query.
+		KeyIDName string
+		// TimestampUnit is the unit of the unix timestamp, default is time.Second.
+		TimestampUnit time.Duration
+		// Canonicalize is default DefaultCanonicalize.
+		Canonicalize CanonicalizeFunc
+		// UnsignedBody leaves the BodyHash empty, so the body is not read, such as the Canonicalize without the body.
+		// Otherwise the body is read into memory to hash, and the streaming body fails with ErrSignatureStreamingBody.
+		UnsignedBody bool
+		// MaxSkew is the max difference between the timestamp and now when verifying,
+		// default is DefaultSignatureMaxSkew, negative disables the check.
+		MaxSkew time.Duration
+		// Now is default time.Now.
+		Now func() time.Time
+	}
+
+	defaultHMACSigner struct {
+		params HMACSignerParams
+	}
+
+	signerTransport struct {
+		signer Signer
+		next   http.RoundTripper
+	}
+)
+
+// NewHMACSigner creates the HMACSigner which signs the requests by HMAC-SHA256,
+// the signature is base64 encoded.
+func NewHMACSigner(params HMACSignerParams) HMACSigner { //nolint:gocritic
+	if params.Location == SignatureInQuery {
+		params.SignatureName = defaultString(params.SignatureName, "signature")
+		params.TimestampName = defaultString(params.TimestampName, "timestamp")
+		params.KeyIDName = defaultString(params.KeyIDName, "key_id")
+	} else {
+		params.SignatureName = defaultString(params.SignatureName, "X-Signature")
+		params.TimestampName = defaultString(params.TimestampName, "X-Signature-Timestamp")
+		params.KeyIDName = defaultString(params.KeyIDName, "X-Signature-Key-Id")
+	}
+	if params.TimestampUnit <= 0 {
+		params.TimestampUnit = time.Second
+	}
+	if params.Canonicalize == nil {
+		params.Canonicalize = DefaultCanonicalize
+	}
+	if params.MaxSkew == 0 {
+		params.MaxSkew = DefaultSignatureMaxSkew
+	}
+	if params.Now == nil {
+		params.Now = time.Now
+	}
+	return &defaultHMACSigner{
+		params: params,
+	}
+}
+
+// WithSigner signs the requests by signer before they're sent, the retried requests are signed again.
+// The later linked transport wrapper can still change the request after it's signed,
+// so it's better to be the last one. It only takes effect for NewClient.
+func WithSigner(signer Signer) RequestOption {
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &signerTransport{signer: signer, next: rt}
+		})
+	}
+}
+
+// DefaultCanonicalize joins the method, path, sorted query, body hash, timestamp and key id with '\n'.
+func DefaultCanonicalize(c *CanonicalRequest) string {
+	return strings.Join([]string{
+		c.Method,
+		c.Path,
+		c.Query.Encode(),
+		c.BodyHash,
+		c.Timestamp,
+		c.KeyID,
+	}, "\n")
+}
+
+// ReadRequestBody reads the body of the request, and sets it back so it can be read again.
+func ReadRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func (f SignerFunc) Sign(r *http.Request) error {
+	if f == nil {
+		return nil
+	}
+	return f(r)
+}
+
+func (s *defaultHMACSigner) Sign(r *http.Request) error {
+	timestamp := strconv.FormatInt(s.params.Now().UnixNano()/int64(s.params.TimestampUnit), 10)
+	if s.params.Location == SignatureInQuery {
+		query := r.URL.Query()
+		query.Del(s.params.SignatureName)
+		query.Set(s.params.TimestampName, timestamp)
+		if s.params.KeyID != "" {
+			query.Set(s.params.KeyIDName, s.params.KeyID)
+		}
+		r.URL.RawQuery = query.Encode()
+	} else {
+		r.Header.Set(s.params.TimestampName, timestamp)
+		if s.params.KeyID != "" {
+			r.Header.Set(s.params.KeyIDName, s.params.KeyID)
+		}
+	}
+
+	c, err := s.canonicalRequest(r)
+	if err != nil {
+		return err
+	}
+	signature := s.sign(c)
+	if s.params.Location == SignatureInQuery {
+		query := r.URL.Query()
+		query.Set(s.params.SignatureName, signature)
+		r.URL.RawQuery = query.Encode()
+	} else {
+		r.Header.Set(s.params.SignatureName, signature)
+	}
+	return nil
+}
+
+// Verify verifies the signature of the request, the error is one of ErrSignatureMissing,
+// ErrSignatureInvalid and ErrSignatureExpired, or the error of reading the body.
+func (s *defaultHMACSigner) Verify(r *http.Request) error {
+	signature := s.get(r, s.params.SignatureName)
+	if signature == "" {
+		return ErrSignatureMissing
+	}
+	if s.params.KeyID != "" && s.get(r, s.params.KeyIDName) != s.params.KeyID {
+		return errors.Wrap(ErrSignatureInvalid, "key id mismatch")
+	}
+
+	c, err := s.canonicalRequest(r)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(c))) {
+		return ErrSignatureInvalid
+	}
+
+	if s.params.MaxSkew > 0 {
+		ts, err := strconv.ParseInt(c.Timestamp, 10, 64)
+		if err != nil {
+			return errors.Wrapf(ErrSignatureInvalid, "timestamp %q", c.Timestamp)
+		}
+		skew := s.params.Now().Sub(time.Unix(0, ts*int64(s.params.TimestampUnit)))
+		if skew > s.params.MaxSkew || skew < -s.params.MaxSkew {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+func (s *defaultHMACSigner) canonicalRequest(r *http.Request) (*CanonicalRequest, error) {
+	var bodyHash string
+	if !s.params.UnsignedBody {
+		if isUploadRequest(r) {
+			return nil, ErrSignatureStreamingBody
+		}
+		body, err := ReadRequestBody(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "read body")
+		}
+		sum := sha256.Sum256(body)
+		bodyHash = hex.EncodeToString(sum[:])
+	}
+
+	query := r.URL.Query()
+	if s.params.Location == SignatureInQuery {
+		query.Del(s.params.SignatureName)
+	}
+	return &CanonicalRequest{
+		Method:    r.Method,
+		Path:      r.URL.EscapedPath(),
+		Query:     query,
+		Header:    r.Header,
+		BodyHash:  bodyHash,
+		Timestamp: s.get(r, s.params.TimestampName),
+		KeyID:     s.get(r, s.params.KeyIDName),
+	}, nil
+}
+
+func (s *defaultHMACSigner) sign(c *CanonicalRequest) string {
+	mac := hmac.New(sha256.New, s.params.Secret)
+	_, _ = mac.Write([]byte(s.params.Canonicalize(c)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *defaultHMACSigner) get(r *http.Request, name string) string {
+	if s.params.Location == SignatureInQuery {
+		return r.URL.Query().Get(name)
+	}
+	return r.Header.Get(name)
+}
+
+func (t *signerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	req := r.Clone(r.Context())
+	if err := t.signer.Sign(req); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, errors.Wrap(err, "sign request")
+	}
+	return t.next.RoundTrip(req)
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,212 @@
+package httpclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCanonicalize(t *testing.T) {
+	ast := assert.New(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/a%2Fb/c?z=1&a=2&a=1", nil)
+	ast.Equal("POST\n/a%2Fb/c\na=2&a=1&z=1\nhash\n123\nk1", DefaultCanonicalize(&CanonicalRequest{
+		Method:    r.Method,
+		Path:      r.URL.EscapedPath(),
+		Query:     r.URL.Query(),
+		BodyHash:  "hash",
+		Timestamp: "123",
+		KeyID:     "k1",
+	}))
+}
+
+func TestReadRequestBody(t *testing.T) {
+	ast := assert.New(t)
+
+	body, err := ReadRequestBody(httptest.NewRequest(http.MethodGet, "/", nil))
+	ast.NoError(err)
+	ast.Nil(body)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("body"))
+	body, err = ReadRequestBody(r)
+	ast.NoError(err)
+	ast.Equal("body", string(body))
+	body, err = io.ReadAll(r.Body)
+	ast.NoError(err)
+	ast.Equal("body", string(body))
+	rc, err := r.GetBody()
+	ast.NoError(err)
+	body, err = io.ReadAll(rc)
+	ast.NoError(err)
+	ast.Equal("body", string(body))
+
+	r.Body = io.NopCloser(errReader{})
+	_, err = ReadRequestBody(r)
+	ast.EqualError(err, "read error")
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestHMACSigner(t *testing.T) {
+	ast := assert.New(t)
+
+	now := time.Unix(1700000000, 0)
+	signer := NewHMACSigner(HMACSignerParams{
+		Secret: []byte("secret"),
+		KeyID:  "k1",
+		Now:    func() time.Time { return now },
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/path?b=2&a=1", strings.NewReader("body"))
+	ast.NoError(signer.Sign(r))
+	ast.Equal("1700000000", r.Header.Get("X-Signature-Timestamp"))
+	ast.Equal("k1", r.Header.Get("X-Signature-Key-Id"))
+
+	bodyHash := sha256.Sum256([]byte("body"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write([]byte("POST\n/path\na=1&b=2\n" + hex.EncodeToString(bodyHash[:]) + "\n1700000000\nk1"))
+	ast.Equal(base64.StdEncoding.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+
+	// the body can be read after signing
+	body, err := io.ReadAll(r.Body)
+	ast.NoError(err)
+	ast.Equal("body", string(body))
+
+	r.Body = io.NopCloser(strings.NewReader("body"))
+	ast.NoError(signer.Verify(r))
+
+	r.Body = io.NopCloser(strings.NewReader("changed"))
+	ast.ErrorIs(signer.Verify(r), ErrSignatureInvalid)
+
+	r.Body = io.NopCloser(strings.NewReader("body"))
+	r.URL.RawQuery = "a=1&b=3"
+	ast.ErrorIs(signer.Verify(r), ErrSignatureInvalid)
+
+	r.Body = io.NopCloser(strings.NewReader("body"))
+	r.URL.RawQuery = "a=1&b=2"
+	r.Header.Set("X-Signature-Key-Id", "k2")
+	ast.ErrorIs(signer.Verify(r), ErrSignatureInvalid)
+	r.Header.Set("X-Signature-Key-Id", "k1")
+
+	// the key id is signed, so it can not be changed even if the verifier accepts any key id
+	verifier := NewHMACSigner(HMACSignerParams{Secret: []byte("secret"), Now: func() time.Time { return now }})
+	ast.NoError(verifier.Verify(r))
+	r.Header.Set("X-Signature-Key-Id", "k2")
+	ast.ErrorIs(verifier.Verify(r), ErrSignatureInvalid)
+	r.Header.Set("X-Signature-Key-Id", "k1")
+
+	now = now.Add(DefaultSignatureMaxSkew + time.Second)
+	ast.ErrorIs(signer.Verify(r), ErrSignatureExpired)
+
+	r.Header.Del("X-Signature")
+	ast.ErrorIs(signer.Verify(r), ErrSignatureMissing)
+}
+
+func TestHMACSignerInQuery(t *testing.T) {
+	ast := assert.New(t)
+
+	signer := NewHMACSigner(HMACSignerParams{
+		Secret:        []byte("secret"),
+		Location:      SignatureInQuery,
+		TimestampUnit: time.Millisecond,
+		MaxSkew:       -1,
+		Canonicalize: func(c *CanonicalRequest) string {
+			return c.Timestamp + "\n" + c.Query.Get("a")
+		},
+		UnsignedBody: true,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/?a=1", nil)
+	ast.NoError(signer.Sign(r))
+	query := r.URL.Query()
+	ast.Equal("1", query.Get("a"))
+	ast.Len(query.Get("timestamp"), 13)
+	ast.NotEmpty(query.Get("signature"))
+	ast.Empty(r.Header.Get("X-Signature"))
+	ast.NoError(signer.Verify(r))
+
+	// signed again
+	ast.NoError(signer.Sign(r))
+	ast.Len(r.URL.Query()["signature"], 1)
+	ast.NoError(signer.Verify(r))
+
+	query.Set("timestamp", "1")
+	r.URL.RawQuery = query.Encode()
+	ast.ErrorIs(signer.Verify(r), ErrSignatureInvalid)
+
+	// the unsigned body is not read
+	body := io.NopCloser(strings.NewReader("body"))
+	r = httptest.NewRequest(http.MethodPost, "/?a=1", nil)
+	r.Body = body
+	ast.NoError(signer.Sign(r))
+	ast.NoError(signer.Verify(r))
+	ast.Equal(body, r.Body)
+}
+
+func TestWithSigner(t *testing.T) {
+	ast := assert.New(t)
+
+	signer := NewHMACSigner(HMACSignerParams{Secret: []byte("secret")})
+	unsignedBodySigner := NewHMACSigner(HMACSignerParams{Secret: []byte("secret"), UnsignedBody: true})
+	var calls int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier := signer
+		if r.URL.Path == "/unsigned-body" {
+			verifier = unsignedBodySigner
+		}
+		if err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer testServer.Close()
+
+	c := NewClient(testServer.URL, WithRetry(RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond}), WithSigner(signer))
+	resp, err := c.Put("/path", map[string]string{"k": "v"}, WithQueryParam("q", "v"))
+	ast.NoError(err)
+	ast.Equal(http.StatusOK, resp.StatusCode())
+	ast.JSONEq(`{"k":"v"}`, string(resp.Body()))
+	ast.EqualValues(2, atomic.LoadInt32(&calls))
+
+	resp, err = NewClient(testServer.URL).Post("/path", "body")
+	ast.NoError(err)
+	ast.Equal(http.StatusUnauthorized, resp.StatusCode())
+	ast.Contains(string(resp.Body()), ErrSignatureMissing.Error())
+
+	c = NewClient(testServer.URL, WithSigner(SignerFunc(func(r *http.Request) error {
+		return errors.New("no key")
+	})))
+	_, err = c.Get("/")
+	ast.ErrorContains(err, "sign request: no key")
+
+	// the streaming body
+	_, err = NewClient(testServer.URL, WithSigner(signer)).Post("/path", nil, WithMultipartField("k", "v"))
+	ast.ErrorIs(err, ErrSignatureStreamingBody)
+
+	atomic.StoreInt32(&calls, 1)
+	resp, err = NewClient(testServer.URL, WithSigner(unsignedBodySigner)).Post("/unsigned-body", nil, WithMultipartField("k", "v"))
+	ast.NoError(err)
+	ast.Equal(http.StatusOK, resp.StatusCode())
+	ast.Contains(string(resp.Body()), `name="k"`)
+}
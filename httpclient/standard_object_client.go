@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+// ErrNotStandardResponse is the cause of ResponseError if the body is not the standard envelope, such as without code.
+var ErrNotStandardResponse = errors.New("not a standard response")
+
+type (
+	// StandardObjectClient is an ObjectClient for the services which respond with the standard envelope,
+	// see response.NewStandardHandler.
+	StandardObjectClient interface {
+		ObjectClient
+	}
+
+	// StandardResponse is the standard envelope of the response.
+	StandardResponse struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data,omitempty"`
+		Details string          `json:"details,omitempty"`
+	}
+
+	defaultStandardObjectClient struct {
+		client Client
+	}
+)
+
+var _ StandardObjectClient = (*defaultStandardObjectClient)(nil)
+
+// NewStandardObjectClient creates a StandardObjectClient which decodes the data field into the response object.
+// The non-zero code is treated as an error even if the http status is 200,
+// the error is a ResponseError caused by an errorx.CodeError with the remote code, message and details,
+// and the *StandardErrorObject is available by GetErrorObject.
+// The cause is converted by StandardErrorConverter unless WithErrorConverter is set,
+// use NewStandardErrorConverter to match the remote code with the registered ones.
+func NewStandardObjectClient(addr string, opts ...RequestOption) StandardObjectClient {
+	return NewStandardObjectClientRaw(NewClient(addr, opts...))
+}
+
+func NewStandardObjectClientRaw(cli Client) StandardObjectClient {
+	return &defaultStandardObjectClient{
+		client: cli,
+	}
+}
+
+func (c *defaultStandardObjectClient) Get(urlPath string, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Get(urlPath, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) Post(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Post(urlPath, body, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) Put(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Put(urlPath, body, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) Patch(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Patch(urlPath, body, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) Delete(urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Delete(urlPath, body, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) Execute(method, urlPath string, body, responseObj interface{}, opts ...RequestOption) error {
+	resp, err := c.client.Execute(method, urlPath, body, opts...)
+	return c.convertResponse(responseObj, resp, err, opts)
+}
+
+func (c *defaultStandardObjectClient) convertResponse(responseObj interface{}, resp *resty.Response, err error, opts []RequestOption) error {
+	if err != nil {
+		return err
+	}
+
+	o := requestOptionsOf(c.client, opts...)
+	var envelope struct {
+		StandardResponse
+		Code *int `json:"code"`
+	}
+	if err = json.Unmarshal(resp.Body(), &envelope); err != nil || envelope.Code == nil {
+		if err := newResponseErrorNotSuccess(resp, o); err != nil {
+			return err
+		}
+		if err == nil {
+			err = ErrNotStandardResponse
+		}
+		return NewResponseError(resp, err)
+	}
+	standardResp := envelope.StandardResponse
+	standardResp.Code = *envelope.Code
+
+	if standardResp.Code != 0 {
+		errorObject := &StandardErrorObject{
+			Code:    standardResp.Code,
+			Message: standardResp.Message,
+			Details: standardResp.Details,
+		}
+		if resp.Request != nil {
+			resp.Request.Error = errorObject
+		}
+		converter := o.errorConverter
+		if converter == nil {
+			converter = StandardErrorConverter
+		}
+		return NewResponseError(resp, converter(resp, errorObject))
+	}
+
+	if err = newResponseErrorNotSuccess(resp, o); err != nil {
+		return err
+	}
+
+	if responseObj == nil || len(standardResp.Data) == 0 {
+		return nil
+	}
+
+	switch v := responseObj.(type) {
+	case *[]byte:
+		*v = append((*v)[:0], standardResp.Data...)
+	case io.Writer:
+		_, err = v.Write(standardResp.Data)
+	default:
+		err = json.Unmarshal(standardResp.Data, responseObj)
+	}
+	if err != nil {
+		return NewResponseError(resp, err)
+	}
+	return nil
+}
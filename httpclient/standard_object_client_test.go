@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+	"github.com/vesoft-inc/go-pkg/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardObjectClient(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	ast := assert.New(t)
+	errUserNotFound := errorx.NewErrCode(errorx.CCNotFound, 1, 1, "ErrUserNotFound")
+	handler := response.NewStandardHandler(response.StandardHandlerParams{
+		DetailsType: response.StandardHandlerDetailsNormal,
+	})
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			handler.Handle(w, r, &user{Name: "n"}, nil)
+		case "/any":
+			handler.Handle(w, r, response.StandardHandlerDataFieldAny([]int{1, 2}), nil)
+		case "/empty":
+			handler.Handle(w, r, nil, nil)
+		case "/not-found":
+			handler.Handle(w, r, nil, errorx.WithCode(errUserNotFound, nil, "user %d", 1))
+		case "/code-with-200":
+			_, _ = io.WriteString(w, `{"code":50000001,"message":"ErrInternal"}`)
+		case "/invalid":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = io.WriteString(w, `Bad Gateway`)
+		case "/invalid-200":
+			_, _ = io.WriteString(w, `invalid`)
+		case "/not-envelope":
+			_, _ = io.WriteString(w, `{"id":1}`)
+		}
+	}))
+	defer testServer.Close()
+
+	c := NewStandardObjectClient(testServer.URL)
+
+	var u user
+	ast.NoError(c.Get("/user", &u))
+	ast.Equal(user{Name: "n"}, u)
+
+	var ints []int
+	ast.NoError(c.Post("/any", nil, &ints))
+	ast.Equal([]int{1, 2}, ints)
+
+	var bs []byte
+	ast.NoError(c.Put("/any", nil, &bs))
+	ast.Equal(`[1,2]`, string(bs))
+
+	ast.NoError(c.Delete("/empty", nil, &u))
+	ast.NoError(c.Patch("/user", nil, nil))
+
+	err := c.Get("/not-found", &u)
+	ast.True(IsResponseError(err, http.StatusNotFound))
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(errUserNotFound.GetCode(), e.GetCode())
+		ast.Equal("ErrUserNotFound", e.GetMessage())
+		ast.Equal("40401001(ErrUserNotFound) user 1", e.GetDetails())
+	}
+	ast.Equal(&StandardErrorObject{
+		Code:    errUserNotFound.GetCode(),
+		Message: "ErrUserNotFound",
+		Details: "40401001(ErrUserNotFound) user 1",
+	}, GetErrorObject(err))
+
+	err = c.Execute(http.MethodGet, "/code-with-200", nil, &u)
+	ast.True(IsResponseError(err, http.StatusOK))
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(50000001, e.GetCode())
+		ast.Equal(500, e.GetCategoryCode())
+	}
+
+	err = c.Get("/invalid", &u)
+	ast.True(IsResponseError(err, http.StatusBadGateway))
+	ast.False(errorx.IsCodeError(err))
+
+	err = c.Get("/invalid-200", &u)
+	ast.True(IsResponseError(err, http.StatusOK))
+
+	u = user{Name: "n"}
+	err = c.Get("/not-envelope", &u)
+	ast.True(IsResponseError(err, http.StatusOK))
+	ast.ErrorIs(err, ErrNotStandardResponse)
+	ast.Equal(user{Name: "n"}, u)
+
+	ast.Error(c.Get("/user", &ints))
+
+	// match the registered codes
+	c = NewStandardObjectClient(testServer.URL, WithErrorConverter(NewStandardErrorConverter(NewErrCodeLookup(errUserNotFound))))
+	err = c.Get("/not-found", &u)
+	ast.True(errorx.IsCodeError(err, errUserNotFound))
+	err = c.Get("/code-with-200", &u)
+	if e, ok := errorx.AsCodeError(err); ast.True(ok) {
+		ast.Equal(50000001, e.GetCode())
+	}
+}
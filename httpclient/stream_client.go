@@ -0,0 +1,338 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultSSEReconnectDelay = 3 * time.Second
+
+	streamMaxLineSize = 1024 * 1024
+)
+
+var (
+	_ StreamClient = (*defaultStreamClient)(nil)
+
+	// ErrStreamStop can be returned by the callback to stop the stream without error.
+	ErrStreamStop = errors.New("stream stop")
+)
+
+type (
+	// StreamClient consumes the streaming responses, such as Server-Sent Events and NDJSON.
+	StreamClient interface {
+		// SSE consumes the Server-Sent Events, and reconnects with Last-Event-ID once the connection is lost.
+		// It returns when the context is done, fn returns an error, or the server responds 204 or non-2xx.
+		SSE(urlPath string, fn func(*Event) error, opts ...RequestOption) error
+		// SSEChan is as same as SSE but yields the events via channel,
+		// the error channel receives the result once the event channel is closed.
+		SSEChan(ctx context.Context, urlPath string, opts ...RequestOption) (<-chan *Event, <-chan error)
+		// NDJSON consumes the newline delimited json records until the response is ended.
+		NDJSON(urlPath string, fn func(*Record) error, opts ...RequestOption) error
+		// NDJSONChan is as same as NDJSON but yields the records via channel,
+		// the error channel receives the result once the record channel is closed.
+		NDJSONChan(ctx context.Context, urlPath string, opts ...RequestOption) (<-chan *Record, <-chan error)
+	}
+
+	// Event is a Server-Sent Event.
+	Event struct {
+		ID    string
+		Event string
+		Data  []byte
+		// Retry is the reconnection time set by the event, it's 0 if not set.
+		Retry time.Duration
+	}
+
+	// Record is a record of NDJSON stream.
+	Record struct {
+		Data []byte
+	}
+
+	defaultStreamClient struct {
+		client Client
+	}
+
+	// sseParser parses the event stream, the last event id and retry are kept across the connections.
+	sseParser struct {
+		lastID string
+		retry  time.Duration
+	}
+
+	streamOptions struct {
+		reconnectSet   bool
+		maxReconnects  int
+		reconnectDelay time.Duration
+	}
+)
+
+func NewStreamClient(addr string, opts ...RequestOption) StreamClient {
+	return NewStreamClientRaw(NewClient(addr, opts...))
+}
+
+func NewStreamClientRaw(cli Client) StreamClient {
+	return &defaultStreamClient{
+		client: cli,
+	}
+}
+
+// WithStreamReconnect sets the max number of reconnections of SSE and the delay before reconnecting,
+// negative maxReconnects means no limit, which is the default, and 0 disables the reconnection.
+// The delay is default DefaultSSEReconnectDelay, and it's replaced by the retry field of events.
+func WithStreamReconnect(maxReconnects int, delay time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.stream.reconnectSet = true
+		o.stream.maxReconnects = maxReconnects
+		o.stream.reconnectDelay = delay
+	}
+}
+
+// Decode decodes the data of event as json into v, see ObjectClient.
+func (e *Event) Decode(v interface{}) error {
+	return decodeBody(ContentTypeJSON, e.Data, v)
+}
+
+// Decode decodes the record into v, see ObjectClient.
+func (r *Record) Decode(v interface{}) error {
+	return decodeBody(ContentTypeJSON, r.Data, v)
+}
+
+func (c *defaultStreamClient) SSE(urlPath string, fn func(*Event) error, opts ...RequestOption) error {
+	var (
+		o          = c.streamOptions(opts)
+		parser     = &sseParser{}
+		reconnects int
+	)
+	for {
+		ctx := context.Background()
+		body, err := c.open(urlPath, opts, func(r *resty.Request) {
+			ctx = r.Context()
+			r.SetHeader("Accept", "text/event-stream")
+			r.SetHeader("Cache-Control", "no-cache")
+			if parser.lastID != "" {
+				r.SetHeader("Last-Event-ID", parser.lastID)
+			}
+		})
+		if err == nil {
+			if body == nil {
+				return nil // 204 No Content
+			}
+			reconnects = 0
+
+			var fnErr error
+			err = parser.parse(body, func(e *Event) error {
+				fnErr = fn(e)
+				return fnErr
+			})
+			_ = body.Close()
+			if fnErr != nil {
+				if errors.Is(fnErr, ErrStreamStop) {
+					return nil
+				}
+				return fnErr
+			}
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil && !isStreamReconnectable(err) {
+			return err
+		}
+		if o.maxReconnects >= 0 && reconnects >= o.maxReconnects {
+			return err
+		}
+		reconnects++
+
+		delay := o.reconnectDelay
+		if parser.retry > 0 {
+			delay = parser.retry
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *defaultStreamClient) SSEChan(ctx context.Context, urlPath string, opts ...RequestOption) (<-chan *Event, <-chan error) {
+	var (
+		ch   = make(chan *Event)
+		errc = make(chan error, 1)
+	)
+	go func() {
+		defer close(errc)
+		defer close(ch)
+		errc <- c.SSE(urlPath, func(e *Event) error {
+			select {
+			case ch <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+	}()
+	return ch, errc
+}
+
+func (c *defaultStreamClient) NDJSON(urlPath string, fn func(*Record) error, opts ...RequestOption) error {
+	body, err := c.open(urlPath, opts, func(r *resty.Request) {
+		r.SetHeader("Accept", "application/x-ndjson")
+	})
+	if err != nil || body == nil {
+		return err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), streamMaxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err = fn(&Record{Data: append([]byte(nil), line...)}); err != nil {
+			if errors.Is(err, ErrStreamStop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *defaultStreamClient) NDJSONChan(ctx context.Context, urlPath string, opts ...RequestOption) (<-chan *Record, <-chan error) {
+	var (
+		ch   = make(chan *Record)
+		errc = make(chan error, 1)
+	)
+	go func() {
+		defer close(errc)
+		defer close(ch)
+		errc <- c.NDJSON(urlPath, func(r *Record) error {
+			select {
+			case ch <- r:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+	}()
+	return ch, errc
+}
+
+// open sends the request without parsing the response, and returns the body.
+// The body is nil if the response is 204 No Content.
+func (c *defaultStreamClient) open(urlPath string, opts []RequestOption, hook func(*resty.Request)) (io.ReadCloser, error) {
+	allOpts := make([]RequestOption, 0, len(opts)+1)
+	allOpts = append(allOpts, opts...)
+	allOpts = append(allOpts, WithBeforeRequestHook(func(r *resty.Request) {
+		r.SetDoNotParseResponse(true)
+		skipCoalescing(r)
+		hook(r)
+	}))
+
+	resp, err := c.client.Get(urlPath, allOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	body := resp.RawBody()
+	if resp.StatusCode() == http.StatusNoContent {
+		_ = body.Close()
+		return nil, nil
+	}
+	if !resp.IsSuccess() {
+		bs, _ := io.ReadAll(io.LimitReader(body, downloadErrorMaxSize))
+		_ = body.Close()
+		resp.SetBody(bs)
+		return nil, newResponseErrorNotSuccess(resp, requestOptionsOf(c.client, opts...))
+	}
+	return body, nil
+}
+
+func (c *defaultStreamClient) streamOptions(opts []RequestOption) streamOptions {
+	o := requestOptionsOf(c.client, opts...).stream
+	if !o.reconnectSet {
+		o.maxReconnects = -1
+	}
+	if o.reconnectDelay <= 0 {
+		o.reconnectDelay = DefaultSSEReconnectDelay
+	}
+	return o
+}
+
+// parse parses the event stream, see https://html.spec.whatwg.org/multipage/server-sent-events.html.
+func (p *sseParser) parse(r io.Reader, fn func(*Event) error) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		event   = &Event{}
+		data    bytes.Buffer
+	)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), streamMaxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))
+
+		if len(line) == 0 {
+			if data.Len() == 0 {
+				event = &Event{}
+				continue
+			}
+			event.ID = p.lastID
+			if event.Event == "" {
+				event.Event = "message"
+			}
+			event.Data = bytes.TrimSuffix(append([]byte(nil), data.Bytes()...), []byte("\n"))
+			if err := fn(event); err != nil {
+				return err
+			}
+			event = &Event{}
+			data.Reset()
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if index := bytes.IndexByte(line, ':'); index >= 0 {
+			field, value = line[:index], bytes.TrimPrefix(line[index+1:], []byte(" "))
+		}
+		switch string(field) {
+		case "event":
+			event.Event = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				p.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+				p.retry = event.Retry
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// isStreamReconnectable reports whether to reconnect after the error.
+func isStreamReconnectable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	_, ok := AsResponseError(err)
+	return !ok
+}
@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamClientSSE(t *testing.T) {
+	var (
+		ast         = assert.New(t)
+		connections int32
+		lastIDs     = make(chan string, 10)
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			lastIDs <- r.Header.Get("Last-Event-ID")
+			ast.Equal("text/event-stream", r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "text/event-stream")
+			switch atomic.AddInt32(&connections, 1) {
+			case 1:
+				_, _ = io.WriteString(w, "retry: 10\n\nid: 1\ndata: {\"n\":1}\n\n: comment\nid: 2\nevent: update\ndata: {\"n\":2}\n\n")
+			case 2:
+				_, _ = io.WriteString(w, "data: {\"n\":3}\n\n")
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		case "/forever":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: 1\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"code":40400000,"message":"ErrNotFound"}`)
+		}
+	}))
+	defer testServer.Close()
+
+	type data struct {
+		N int `json:"n"`
+	}
+
+	c := NewStreamClient(testServer.URL)
+
+	var events []*Event
+	err := c.SSE("/events", func(e *Event) error {
+		events = append(events, e)
+		return nil
+	})
+	ast.NoError(err)
+	if ast.Len(events, 3) {
+		ast.Equal(&Event{ID: "1", Event: "message", Data: []byte(`{"n":1}`)}, events[0])
+		ast.Equal(&Event{ID: "2", Event: "update", Data: []byte(`{"n":2}`)}, events[1])
+		ast.Equal(&Event{ID: "2", Event: "message", Data: []byte(`{"n":3}`)}, events[2])
+		var d data
+		ast.NoError(events[2].Decode(&d))
+		ast.Equal(3, d.N)
+	}
+	ast.Equal("", <-lastIDs)
+	ast.Equal("2", <-lastIDs)
+	ast.Equal("2", <-lastIDs)
+
+	// stop by the callback
+	atomic.StoreInt32(&connections, 0)
+	events = nil
+	err = c.SSE("/events", func(e *Event) error {
+		events = append(events, e)
+		return ErrStreamStop
+	})
+	ast.NoError(err)
+	ast.Len(events, 1)
+
+	testErr := errors.New("test")
+	atomic.StoreInt32(&connections, 0)
+	err = c.SSE("/events", func(e *Event) error {
+		return testErr
+	})
+	ast.ErrorIs(err, testErr)
+
+	// no reconnection
+	atomic.StoreInt32(&connections, 0)
+	events = nil
+	err = c.SSE("/events", func(e *Event) error {
+		events = append(events, e)
+		return nil
+	}, WithStreamReconnect(0, 0))
+	ast.NoError(err)
+	ast.Len(events, 2)
+
+	// the options of the raw client
+	atomic.StoreInt32(&connections, 0)
+	events = nil
+	err = NewStreamClientRaw(NewClient(testServer.URL, WithStreamReconnect(0, 0))).SSE("/events", func(e *Event) error {
+		events = append(events, e)
+		return nil
+	})
+	ast.NoError(err)
+	ast.Len(events, 2)
+
+	// the connection error is reconnected up to the limit
+	err = NewStreamClient("http://127.0.0.1:1").SSE("/", func(e *Event) error {
+		return nil
+	}, WithStreamReconnect(2, time.Millisecond))
+	ast.Error(err)
+
+	// non-2xx
+	err = c.SSE("/not-found", func(e *Event) error {
+		return nil
+	})
+	ast.True(IsResponseError(err, http.StatusNotFound))
+
+	// context canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	err = c.SSE("/forever", func(e *Event) error {
+		cancel()
+		return nil
+	}, WithContext(ctx))
+	ast.ErrorIs(err, context.Canceled)
+
+	// channel
+	atomic.StoreInt32(&connections, 0)
+	ch, errc := c.SSEChan(context.Background(), "/events")
+	var ids []string
+	for e := range ch {
+		ids = append(ids, e.ID)
+	}
+	ast.NoError(<-errc)
+	ast.Equal([]string{"1", "2", "2"}, ids)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	ch, errc = c.SSEChan(ctx, "/forever")
+	<-ch
+	cancel()
+	for range ch {
+	}
+	ast.ErrorIs(<-errc, context.Canceled)
+}
+
+func TestStreamClientNDJSON(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/records":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, "{\"n\":1}\n\n{\"n\":2}\r\n{\"n\":3}")
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer testServer.Close()
+
+	type data struct {
+		N int `json:"n"`
+	}
+
+	c := NewStreamClientRaw(NewClient(testServer.URL))
+
+	var ns []int
+	err := c.NDJSON("/records", func(r *Record) error {
+		var d data
+		if err := r.Decode(&d); err != nil {
+			return err
+		}
+		ns = append(ns, d.N)
+		return nil
+	})
+	ast.NoError(err)
+	ast.Equal([]int{1, 2, 3}, ns)
+
+	ns = nil
+	err = c.NDJSON("/records", func(r *Record) error {
+		ns = append(ns, 0)
+		return ErrStreamStop
+	})
+	ast.NoError(err)
+	ast.Len(ns, 1)
+
+	ast.NoError(c.NDJSON("/empty", func(r *Record) error {
+		ast.Fail("unexpected record")
+		return nil
+	}))
+
+	err = c.NDJSON("/error", func(r *Record) error {
+		return nil
+	})
+	ast.True(IsResponseError(err, http.StatusInternalServerError))
+
+	ch, errc := c.NDJSONChan(context.Background(), "/records")
+	var records []string
+	for r := range ch {
+		records = append(records, string(r.Data))
+	}
+	ast.NoError(<-errc)
+	ast.Equal([]string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, records)
+}
+
+func TestSSEParser(t *testing.T) {
+	ast := assert.New(t)
+
+	p := &sseParser{lastID: "0"}
+	var events []*Event
+	err := p.parse(strings.NewReader(strings.Join([]string{
+		"data: line1",
+		"data:line2",
+		"",
+		"id: 5",
+		"retry: invalid",
+		"",
+		"event: e",
+		"data",
+		"unknown: field",
+		"retry: 100",
+		"",
+		"id: 6\x00",
+		"data: without dispatch",
+	}, "\r\n")), func(e *Event) error {
+		events = append(events, e)
+		return nil
+	})
+	ast.NoError(err)
+	if ast.Len(events, 2) {
+		ast.Equal(&Event{ID: "0", Event: "message", Data: []byte("line1\nline2")}, events[0])
+		ast.Equal(&Event{ID: "5", Event: "e", Data: []byte(""), Retry: 100 * time.Millisecond}, events[1])
+	}
+	ast.Equal("5", p.lastID)
+	ast.Equal(100*time.Millisecond, p.retry)
+}
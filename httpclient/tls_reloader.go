@@ -0,0 +1,295 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+const DefaultTLSReloadInterval = 10 * time.Second
+
+var (
+	ErrTLSNoCertificate = errors.New("no certificate found")
+	ErrTLSNoServerName  = errors.New("no server name to verify the certificate")
+
+	_ TLSReloader     = (*defaultTLSReloader)(nil)
+	_ tlsConfigGetter = (*defaultTLSReloader)(nil)
+)
+
+type (
+	// TLSReloader loads the client certificate and CA bundle from the files, and reloads them once they're changed.
+	TLSReloader interface {
+		// Config returns the tls config which uses the latest loaded certificate and CA bundle
+		// for the new connections, the existing connections are not affected.
+		// The server is verified by the ServerName of the connection, which is not available for the IP addresses,
+		// so the ServerName of the base config is required to connect to them, otherwise ErrTLSNoServerName is returned.
+		// WithTLSReloader verifies the address dialed instead.
+		Config() *tls.Config
+		// Reload loads the files immediately, the previous ones are kept if it's failed.
+		Reload() error
+		// Close stops watching the files.
+		Close()
+	}
+
+	TLSReloaderParams struct {
+		// CertFile and KeyFile are the PEM encoded client certificate and key, they're optional.
+		CertFile string
+		KeyFile  string
+		// CAFile is the PEM encoded CA bundle to verify the server, the system roots are used if it's empty.
+		CAFile string
+		// Config is the base tls config, it's cloned.
+		Config *tls.Config
+		// Interval is the interval of checking the modification of the files, default is DefaultTLSReloadInterval.
+		// Negative value disables watching, and Reload is called manually.
+		Interval time.Duration
+		// OnError is called with the error of reloading in background.
+		OnError func(err error)
+	}
+
+	defaultTLSReloader struct {
+		params TLSReloaderParams
+
+		mu      sync.RWMutex
+		cert    *tls.Certificate
+		pool    *x509.CertPool
+		modTime map[string]time.Time
+
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+
+	// tlsConfigGetter is implemented by the TLSReloader which creates the tls config for the host dialed.
+	tlsConfigGetter interface {
+		configFor(serverName string) *tls.Config
+	}
+)
+
+// NewTLSReloader creates the TLSReloader, it fails if the files can not be loaded at first.
+// Close it to stop the background goroutine if Interval is not negative.
+func NewTLSReloader(params TLSReloaderParams) (TLSReloader, error) { //nolint:gocritic
+	if params.Interval == 0 {
+		params.Interval = DefaultTLSReloadInterval
+	}
+	if params.Config == nil {
+		params.Config = &tls.Config{} //nolint:gosec
+	}
+
+	r := &defaultTLSReloader{
+		params: params,
+		done:   make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if params.Interval > 0 {
+		go r.watch()
+	}
+	return r, nil
+}
+
+// WithTLSReloader sets the tls config of reloader, see WithTLSClientConfig.
+// The server is verified by the host dialed, including the IP address, unless it's connected by proxy.
+func WithTLSReloader(reloader TLSReloader) RequestOption {
+	return func(o *requestOptions) {
+		o.linkNewClientHook(func(c *resty.Client) {
+			c.SetTLSClientConfig(reloader.Config())
+			g, ok := reloader.(tlsConfigGetter)
+			if !ok {
+				return
+			}
+			if t, err := c.Transport(); err == nil {
+				t.DialTLSContext = dialTLSContext(t, g)
+			}
+		})
+	}
+}
+
+func (r *defaultTLSReloader) Config() *tls.Config {
+	return r.configFor("")
+}
+
+// configFor returns the tls config which verifies the server by serverName if the ServerName of base config is empty.
+func (r *defaultTLSReloader) configFor(serverName string) *tls.Config {
+	config := r.params.Config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = serverName
+	}
+	if r.params.CertFile != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		}
+	}
+	if r.params.CAFile != "" && !config.InsecureSkipVerify {
+		// verify by VerifyConnection with the latest CA bundle instead of the static RootCAs
+		config.InsecureSkipVerify = true
+		verifyConnection := config.VerifyConnection
+		serverName := config.ServerName
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if err := r.verify(cs, serverName); err != nil {
+				return err
+			}
+			if verifyConnection != nil {
+				return verifyConnection(cs)
+			}
+			return nil
+		}
+	}
+	return config
+}
+
+func (r *defaultTLSReloader) Reload() error {
+	var (
+		cert    *tls.Certificate
+		pool    *x509.CertPool
+		modTime = map[string]time.Time{}
+	)
+	for _, file := range []string{r.params.CertFile, r.params.KeyFile, r.params.CAFile} {
+		if file == "" {
+			continue
+		}
+		stat, err := os.Stat(file)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		modTime[file] = stat.ModTime()
+	}
+
+	if r.params.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.params.CertFile, r.params.KeyFile)
+		if err != nil {
+			return errors.Wrapf(err, "load certificate %s", r.params.CertFile)
+		}
+		cert = &c
+	}
+	if r.params.CAFile != "" {
+		data, err := os.ReadFile(r.params.CAFile)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.Wrapf(ErrTLSNoCertificate, "load CA %s", r.params.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.pool, r.modTime = cert, pool, modTime
+	return nil
+}
+
+func (r *defaultTLSReloader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+func (r *defaultTLSReloader) watch() {
+	ticker := time.NewTicker(r.params.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil && r.params.OnError != nil {
+				r.params.OnError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether any file is modified since the last reloading,
+// so the error of the same files is reported only once.
+func (r *defaultTLSReloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for file, modTime := range r.modTime {
+		var current time.Time
+		if stat, err := os.Stat(file); err == nil {
+			current = stat.ModTime()
+		}
+		if !current.Equal(modTime) {
+			changed = true
+			r.modTime[file] = current
+		}
+	}
+	return changed
+}
+
+// verify verifies the peer certificate by the CA bundle loaded, the serverName is used if the IP address is connected,
+// since it's not sent in SNI, so the ServerName of cs is empty.
+func (r *defaultTLSReloader) verify(cs tls.ConnectionState, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no peer certificate")
+	}
+	if cs.ServerName != "" {
+		serverName = cs.ServerName
+	}
+	if serverName == "" {
+		return ErrTLSNoServerName
+	}
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// dialTLSContext dials the tls connection with the config of g for the host dialed,
+// the NextProtos of the transport are kept for http2.
+func dialTLSContext(t *http.Transport, g tlsConfigGetter) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		dial := t.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		config := g.configFor(host)
+		if t.TLSClientConfig != nil {
+			config.NextProtos = t.TLSClientConfig.NextProtos
+		}
+		if t.TLSHandshakeTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.TLSHandshakeTimeout)
+			defer cancel()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
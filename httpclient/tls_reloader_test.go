@@ -0,0 +1,286 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates the certificate for 127.0.0.1, or the dnsNames without IP addresses if they're given.
+func newTestCert(t *testing.T, cn string, parent *testCert, dnsNames ...string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if len(dnsNames) > 0 {
+		template.IPAddresses, template.DNSNames = nil, dnsNames
+	}
+	parentCert, parentKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	assert.NoError(t, err)
+	return cert
+}
+
+// newTestMTLSServer responds the common name of the client certificate.
+func newTestMTLSServer(t *testing.T, ca, serverCert *testCert) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close") // handshake again for every request
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	s.TLS = &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestTLSReloader(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		ca1     = newTestCert(t, "ca1", nil)
+		ca2     = newTestCert(t, "ca2", nil)
+		server1 = newTestMTLSServer(t, ca1, newTestCert(t, "server1", ca1))
+		server2 = newTestMTLSServer(t, ca1, newTestCert(t, "server2", ca2))
+		client1 = newTestCert(t, "client1", ca1)
+		client2 = newTestCert(t, "client2", ca1)
+
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+		caFile   = filepath.Join(dir, "ca.pem")
+		modTime  = time.Now().Add(-time.Minute)
+	)
+	writeTestFile(t, certFile, client1.certPEM, modTime)
+	writeTestFile(t, keyFile, client1.keyPEM, modTime)
+	writeTestFile(t, caFile, ca1.certPEM, modTime)
+
+	_, err := NewTLSReloader(TLSReloaderParams{CertFile: certFile, KeyFile: caFile})
+	ast.Error(err)
+
+	reloader, err := NewTLSReloader(TLSReloaderParams{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		CAFile:   caFile,
+		Interval: -1,
+	})
+	if !ast.NoError(err) {
+		return
+	}
+	defer reloader.Close()
+	c := NewBytesClient("", WithTLSReloader(reloader))
+
+	data, err := c.Get(server1.URL)
+	ast.NoError(err)
+	ast.Equal("client1", string(data))
+	_, err = c.Get(server2.URL)
+	ast.ErrorContains(err, "certificate signed by unknown authority")
+
+	// rotate the certificate
+	writeTestFile(t, certFile, client2.certPEM, modTime)
+	writeTestFile(t, keyFile, client2.keyPEM, modTime)
+	ast.NoError(reloader.Reload())
+	data, err = c.Get(server1.URL)
+	ast.NoError(err)
+	ast.Equal("client2", string(data))
+
+	// rotate the CA bundle
+	writeTestFile(t, caFile, append(append([]byte{}, ca1.certPEM...), ca2.certPEM...), modTime)
+	ast.NoError(reloader.Reload())
+	data, err = c.Get(server2.URL)
+	ast.NoError(err)
+	ast.Equal("client2", string(data))
+
+	// the previous ones are kept if it's failed
+	writeTestFile(t, caFile, []byte("invalid"), modTime)
+	ast.ErrorIs(reloader.Reload(), ErrTLSNoCertificate)
+	writeTestFile(t, keyFile, client1.keyPEM, modTime)
+	ast.Error(reloader.Reload())
+	data, err = c.Get(server2.URL)
+	ast.NoError(err)
+	ast.Equal("client2", string(data))
+}
+
+func TestTLSReloaderServerName(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		ca     = newTestCert(t, "ca", nil)
+		server = newTestMTLSServer(t, ca, newTestCert(t, "server", ca, "localhost"))
+		client = newTestCert(t, "client", ca)
+
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+		caFile   = filepath.Join(dir, "ca.pem")
+	)
+	writeTestFile(t, certFile, client.certPEM, time.Now())
+	writeTestFile(t, keyFile, client.keyPEM, time.Now())
+	writeTestFile(t, caFile, ca.certPEM, time.Now())
+
+	newReloader := func(config *tls.Config) TLSReloader {
+		reloader, err := NewTLSReloader(TLSReloaderParams{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			CAFile:   caFile,
+			Config:   config,
+			Interval: -1,
+		})
+		ast.NoError(err)
+		return reloader
+	}
+
+	// the certificate of localhost can not be used for the IP address
+	reloader := newReloader(nil)
+	defer reloader.Close()
+	c := NewBytesClient("", WithTLSReloader(reloader))
+	_, err := c.Get(server.URL)
+	ast.ErrorContains(err, "doesn't contain any IP SANs")
+	data, err := c.Get(strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+	ast.NoError(err)
+	ast.Equal("client", string(data))
+
+	// the config is used without WithTLSReloader
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: reloader.Config()}}
+	_, err = hc.Get(server.URL) //nolint:noctx
+	ast.ErrorIs(err, ErrTLSNoServerName)
+
+	reloader = newReloader(&tls.Config{ServerName: "localhost"}) //nolint:gosec
+	defer reloader.Close()
+	hc = &http.Client{Transport: &http.Transport{TLSClientConfig: reloader.Config()}}
+	resp, err := hc.Get(server.URL) //nolint:noctx
+	if ast.NoError(err) {
+		_ = resp.Body.Close()
+		ast.Equal(http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestTLSReloaderWatch(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		ca      = newTestCert(t, "ca", nil)
+		server  = newTestMTLSServer(t, ca, newTestCert(t, "server", ca))
+		client1 = newTestCert(t, "client1", ca)
+		client2 = newTestCert(t, "client2", ca)
+
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+		modTime  = time.Now().Add(-time.Minute)
+
+		mu   sync.Mutex
+		errs []error
+	)
+	writeTestFile(t, certFile, client1.certPEM, modTime)
+	writeTestFile(t, keyFile, client1.keyPEM, modTime)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	reloader, err := NewTLSReloader(TLSReloaderParams{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		Config:   &tls.Config{RootCAs: pool}, //nolint:gosec
+		Interval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	if !ast.NoError(err) {
+		return
+	}
+	defer reloader.Close()
+	c := NewBytesClient("", WithTLSReloader(reloader))
+
+	data, err := c.Get(server.URL)
+	ast.NoError(err)
+	ast.Equal("client1", string(data))
+
+	// the key is mismatched until it's written
+	writeTestFile(t, certFile, client2.certPEM, modTime.Add(time.Second))
+	ast.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) == 1
+	}, time.Second, 10*time.Millisecond)
+	data, err = c.Get(server.URL)
+	ast.NoError(err)
+	ast.Equal("client1", string(data))
+
+	writeTestFile(t, keyFile, client2.keyPEM, modTime.Add(time.Second))
+	ast.Eventually(func() bool {
+		data, err = c.Get(server.URL)
+		return err == nil && string(data) == "client2"
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	ast.Len(errs, 1, "the error is reported once")
+	mu.Unlock()
+
+	reloader.Close()
+	reloader.Close()
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const DefaultTokenEarlyRefresh = 10 * time.Second
+
+var (
+	_ TokenSource            = TokenSourceFunc(nil)
+	_ RefreshableTokenSource = (*refreshableTokenSource)(nil)
+	_ http.RoundTripper      = (*tokenTransport)(nil)
+)
+
+type (
+	// Token is the access token sent in the Authorization header.
+	Token struct {
+		AccessToken string
+		// TokenType is the type of the token, default is Bearer.
+		TokenType string
+		// Expiry is the expiration time of the token, zero means it never expires.
+		Expiry time.Time
+	}
+
+	// TokenSource returns the token for the requests.
+	TokenSource interface {
+		Token(ctx context.Context) (*Token, error)
+	}
+
+	// RefreshableTokenSource is the TokenSource which caches the token until it's expired or invalidated.
+	RefreshableTokenSource interface {
+		TokenSource
+		// Invalidate invalidates the token if it's the cached one, so the next Token call refreshes it.
+		Invalidate(t *Token)
+	}
+
+	TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+	refreshableTokenSource struct {
+		src          TokenSource
+		earlyRefresh time.Duration
+		mu           sync.Mutex
+		token        *Token
+		group        singleflight.Group
+	}
+
+	tokenTransport struct {
+		ts   TokenSource
+		next http.RoundTripper
+	}
+)
+
+// NewRefreshableTokenSource caches the token of src, and refreshes it earlyRefresh before it's expired.
+// The concurrent refreshes are collapsed into one, and the cached token is used if it's failed to refresh early.
+// The earlyRefresh is default DefaultTokenEarlyRefresh.
+func NewRefreshableTokenSource(src TokenSource, earlyRefresh time.Duration) RefreshableTokenSource {
+	if earlyRefresh <= 0 {
+		earlyRefresh = DefaultTokenEarlyRefresh
+	}
+	return &refreshableTokenSource{
+		src:          src,
+		earlyRefresh: earlyRefresh,
+	}
+}
+
+// WithTokenSource sets the Authorization header by the token of ts.
+// If ts is a RefreshableTokenSource, the token is invalidated once the server responds 401,
+// and the request is retried once with the refreshed token if the body can be sent again.
+// It only takes effect for NewClient.
+func WithTokenSource(ts TokenSource) RequestOption {
+	return func(o *requestOptions) {
+		o.linkTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &tokenTransport{ts: ts, next: rt}
+		})
+	}
+}
+
+// Valid reports whether the token is not empty and not expired.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && !t.expired(0)
+}
+
+// Type returns the type of the token, default is Bearer.
+func (t *Token) Type() string {
+	if t.TokenType == "" {
+		return "Bearer"
+	}
+	return t.TokenType
+}
+
+// expired reports whether the token is expired after d.
+func (t *Token) expired(d time.Duration) bool {
+	return !t.Expiry.IsZero() && !time.Now().Add(d).Before(t.Expiry)
+}
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return f(ctx)
+}
+
+func (s *refreshableTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+	if token.Valid() && !token.expired(s.earlyRefresh) {
+		return token, nil
+	}
+
+	ch := s.group.DoChan("", func() (interface{}, error) {
+		t, err := s.src.Token(&detachedContext{parent: ctx}) // not canceled by one of the callers
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.token = t
+		s.mu.Unlock()
+		return t, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			if token.Valid() {
+				return token, nil // refresh early again next time
+			}
+			return nil, res.Err
+		}
+		return res.Val.(*Token), nil
+	}
+}
+
+func (s *refreshableTokenSource) Invalidate(t *Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == t {
+		s.token = nil
+	}
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.ts.Token(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(t.authorize(r, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	rts, ok := t.ts.(RefreshableTokenSource)
+	if !ok {
+		return resp, nil
+	}
+	rts.Invalidate(token)
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err = rts.Token(r.Context())
+	if err != nil {
+		return resp, nil //nolint:nilerr // respond the 401 if it's failed to refresh
+	}
+	req := t.authorize(r, token)
+	if r.GetBody != nil {
+		if req.Body, err = r.GetBody(); err != nil {
+			return resp, nil //nolint:nilerr
+		}
+	}
+	_ = resp.Body.Close()
+	return t.next.RoundTrip(req)
+}
+
+// authorize returns the copy of r with the Authorization header.
+func (*tokenTransport) authorize(r *http.Request, token *Token) *http.Request {
+	req := r.Clone(r.Context())
+	if token != nil {
+		req.Header.Set("Authorization", token.Type()+" "+token.AccessToken)
+	}
+	return req
+}
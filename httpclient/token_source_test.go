@@ -0,0 +1,181 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	ast := assert.New(t)
+
+	var token *Token
+	ast.False(token.Valid())
+	ast.False((&Token{}).Valid())
+	ast.True((&Token{AccessToken: "t"}).Valid())
+	ast.True((&Token{AccessToken: "t", Expiry: time.Now().Add(time.Minute)}).Valid())
+	ast.False((&Token{AccessToken: "t", Expiry: time.Now().Add(-time.Second)}).Valid())
+
+	ast.Equal("Bearer", (&Token{}).Type())
+	ast.Equal("MAC", (&Token{TokenType: "MAC"}).Type())
+}
+
+func TestRefreshableTokenSource(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		calls  int32
+		expiry = time.Minute
+		fail   int32
+	)
+	ts := NewRefreshableTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		if atomic.LoadInt32(&fail) == 1 {
+			return nil, errors.New("refresh failed")
+		}
+		return &Token{AccessToken: fmt.Sprint(n), Expiry: time.Now().Add(expiry)}, nil
+	}), time.Second)
+
+	// single-flight refresh
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := ts.Token(context.Background())
+			ast.NoError(err)
+			ast.Equal("1", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+	ast.EqualValues(1, atomic.LoadInt32(&calls))
+
+	// cached
+	token, err := ts.Token(context.Background())
+	ast.NoError(err)
+	ast.Equal("1", token.AccessToken)
+
+	// invalidate
+	ts.Invalidate(&Token{AccessToken: "1"})
+	token2, err := ts.Token(context.Background())
+	ast.NoError(err)
+	ast.Same(token, token2, "only the cached token is invalidated")
+	ts.Invalidate(token)
+	token, err = ts.Token(context.Background())
+	ast.NoError(err)
+	ast.Equal("2", token.AccessToken)
+
+	// refresh early, the cached one is used if it's failed
+	expiry = 500 * time.Millisecond
+	ts.Invalidate(token)
+	token, err = ts.Token(context.Background())
+	ast.NoError(err)
+	ast.Equal("3", token.AccessToken)
+	atomic.StoreInt32(&fail, 1)
+	token, err = ts.Token(context.Background())
+	ast.NoError(err)
+	ast.Equal("3", token.AccessToken)
+	ast.EqualValues(4, atomic.LoadInt32(&calls))
+
+	ts.Invalidate(token)
+	_, err = ts.Token(context.Background())
+	ast.EqualError(err, "refresh failed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ts.Token(ctx)
+	ast.ErrorIs(err, context.Canceled)
+}
+
+func TestWithTokenSource(t *testing.T) {
+	ast := assert.New(t)
+
+	var (
+		mu     sync.Mutex
+		valid  = "t1"
+		bodies []string
+	)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer testServer.Close()
+
+	var calls int32
+	ts := NewRefreshableTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return &Token{AccessToken: fmt.Sprintf("t%d", atomic.AddInt32(&calls, 1))}, nil
+	}), 0)
+	c := NewClient(testServer.URL, WithTokenSource(ts))
+
+	resp, err := c.Get("/")
+	ast.NoError(err)
+	ast.Equal("Bearer t1", string(resp.Body()))
+
+	// retry once on 401 after refreshing
+	mu.Lock()
+	valid = "t2"
+	bodies = nil
+	mu.Unlock()
+	resp, err = c.Put("/", "body")
+	ast.NoError(err)
+	ast.Equal("Bearer t2", string(resp.Body()))
+	ast.Equal([]string{"body", "body"}, bodies)
+
+	mu.Lock()
+	valid = "never"
+	bodies = nil
+	mu.Unlock()
+	resp, err = c.Get("/")
+	ast.NoError(err)
+	ast.Equal(http.StatusUnauthorized, resp.StatusCode())
+	ast.Len(bodies, 2)
+
+	// the body which can not be sent again is not retried
+	mu.Lock()
+	bodies = nil
+	mu.Unlock()
+	req, err := http.NewRequest(http.MethodPut, testServer.URL, struct{ io.Reader }{strings.NewReader("body")})
+	ast.NoError(err)
+	rawResp, err := (&tokenTransport{ts: ts, next: http.DefaultTransport}).RoundTrip(req)
+	if ast.NoError(err) {
+		_ = rawResp.Body.Close()
+		ast.Equal(http.StatusUnauthorized, rawResp.StatusCode)
+	}
+	ast.Len(bodies, 1)
+
+	// the static token source is not retried
+	c = NewClient(testServer.URL, WithTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return &Token{AccessToken: "static", TokenType: "Token"}, nil
+	})))
+	mu.Lock()
+	bodies = nil
+	mu.Unlock()
+	resp, err = c.Get("/")
+	ast.NoError(err)
+	ast.Equal(http.StatusUnauthorized, resp.StatusCode())
+	ast.Len(bodies, 1)
+
+	c = NewClient(testServer.URL, WithTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		return nil, errors.New("no token")
+	})))
+	_, err = c.Get("/")
+	ast.ErrorContains(err, "no token")
+}
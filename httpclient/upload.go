@@ -0,0 +1,325 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type (
+	multipartPart struct {
+		fieldName string
+		fileName  string
+		value     string // the value of form field if it's not a file
+		isFile    bool
+		filePath  string
+		reader    io.Reader
+		offset    int64 // the initial offset of the reader if it's an io.Seeker
+		data      []byte
+	}
+
+	// uploadBody is the streaming request body, which reports the progress of uploading.
+	uploadBody struct {
+		io.Reader
+		closer      io.Closer
+		closeOnce   sync.Once
+		rewindable  bool
+		sized       bool // the total is exact, so it's sent as Content-Length
+		progress    ProgressFunc
+		total       int64
+		transferred int64
+	}
+
+	// uploadBodyKey is the context key of the uploadBody of the request.
+	uploadBodyKey struct{}
+
+	// countWriter counts the bytes written.
+	countWriter struct {
+		n int64
+	}
+)
+
+// WithMultipartField adds a form field to the multipart body.
+func WithMultipartField(fieldName, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.appendMultipartPart(&multipartPart{fieldName: fieldName, value: value})
+	}
+}
+
+// WithMultipartFile adds the file of the path to the multipart body, the file is opened for each attempt.
+func WithMultipartFile(fieldName, filePath string) RequestOption {
+	return func(o *requestOptions) {
+		o.appendMultipartPart(&multipartPart{
+			fieldName: fieldName,
+			fileName:  filepath.Base(filePath),
+			isFile:    true,
+			filePath:  filePath,
+		})
+	}
+}
+
+// WithMultipartReader adds the content of r as a file to the multipart body.
+// The request can be retried only if r is an io.Seeker, see WithRetry.
+func WithMultipartReader(fieldName, fileName string, r io.Reader) RequestOption {
+	part := &multipartPart{
+		fieldName: fieldName,
+		fileName:  fileName,
+		isFile:    true,
+		reader:    r,
+	}
+	if s, ok := r.(io.Seeker); ok {
+		part.offset, _ = s.Seek(0, io.SeekCurrent)
+	}
+	return func(o *requestOptions) {
+		o.appendMultipartPart(part)
+	}
+}
+
+// WithMultipartBytes adds the data as a file to the multipart body.
+func WithMultipartBytes(fieldName, fileName string, data []byte) RequestOption {
+	return func(o *requestOptions) {
+		o.appendMultipartPart(&multipartPart{
+			fieldName: fieldName,
+			fileName:  fileName,
+			isFile:    true,
+			data:      data,
+		})
+	}
+}
+
+// WithUploadProgress reports the progress of uploading the request body, the total is -1 if it's unknown.
+// The []byte, string and the io.Reader with Len method are sent with Content-Length by NewClient,
+// the others are streamed with chunked transfer encoding.
+func WithUploadProgress(fn ProgressFunc) RequestOption {
+	return func(o *requestOptions) {
+		o.uploadProgress = fn
+	}
+}
+
+// appendMultipartPart appends the part without modifying the parts shared with the copied options.
+func (o *requestOptions) appendMultipartPart(part *multipartPart) {
+	parts := make([]*multipartPart, len(o.multipartParts), len(o.multipartParts)+1)
+	copy(parts, o.multipartParts)
+	o.multipartParts = append(parts, part)
+}
+
+// prepareUploadBody sets the multipart body which is written by a goroutine in streaming,
+// and wraps the body to report the progress.
+func (o *requestOptions) prepareUploadBody(r *resty.Request) error {
+	if len(o.multipartParts) > 0 {
+		return o.prepareMultipartBody(r)
+	}
+	if o.uploadProgress == nil || r.Body == nil {
+		return nil
+	}
+
+	body := &uploadBody{
+		rewindable: isBodyRewindable(r.Body),
+		progress:   o.uploadProgress,
+		total:      -1,
+	}
+	switch v := r.Body.(type) {
+	case []byte:
+		body.Reader, body.total = bytes.NewReader(v), int64(len(v))
+	case string:
+		body.Reader, body.total = bytes.NewReader([]byte(v)), int64(len(v))
+	case io.Reader:
+		body.Reader, body.total = v, readerSize(v)
+	default:
+		return nil // the body is encoded by resty
+	}
+	body.sized = body.total >= 0
+	r.SetBody(body)
+	r.SetContext(context.WithValue(r.Context(), uploadBodyKey{}, body))
+	return nil
+}
+
+// isUploadRequest reports whether the body of r is streamed by the uploadBody, such as the multipart body.
+func isUploadRequest(r *http.Request) bool {
+	_, ok := r.Context().Value(uploadBodyKey{}).(*uploadBody)
+	return ok
+}
+
+// setUploadContentLength is the pre-request hook of resty, which sets the Content-Length of the sized uploadBody,
+// since resty only sets it for the body encoded by itself.
+func setUploadContentLength(_ *resty.Client, r *http.Request) error {
+	if b, ok := r.Context().Value(uploadBodyKey{}).(*uploadBody); ok && b.sized {
+		if b.total == 0 {
+			r.Body = http.NoBody
+		}
+		r.ContentLength = b.total
+	}
+	return nil
+}
+
+func (o *requestOptions) prepareMultipartBody(r *resty.Request) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	total, rewindable := o.multipartSize(mw.Boundary())
+	go func() {
+		err := o.writeMultipartParts(mw)
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	body := &uploadBody{
+		Reader:     pr,
+		closer:     pr,
+		rewindable: rewindable,
+		progress:   o.uploadProgress,
+		total:      total,
+	}
+	r.SetHeader("Content-Type", mw.FormDataContentType())
+	r.SetBody(body)
+	r.SetContext(context.WithValue(r.Context(), uploadBodyKey{}, body))
+	return nil
+}
+
+func (o *requestOptions) writeMultipartParts(mw *multipart.Writer) error {
+	for _, part := range o.multipartParts {
+		if !part.isFile {
+			if err := mw.WriteField(part.fieldName, part.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		w, err := mw.CreateFormFile(part.fieldName, part.fileName)
+		if err != nil {
+			return err
+		}
+		if err = part.writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multipartSize returns the size of multipart body, and whether it can be rewound.
+func (o *requestOptions) multipartSize(boundary string) (size int64, rewindable bool) {
+	var (
+		cw = &countWriter{}
+		mw = multipart.NewWriter(cw)
+	)
+	_ = mw.SetBoundary(boundary)
+
+	size, rewindable = 0, true
+	for _, part := range o.multipartParts {
+		if !part.isFile {
+			_ = mw.WriteField(part.fieldName, part.value)
+			continue
+		}
+		_, _ = mw.CreateFormFile(part.fieldName, part.fileName)
+		if part.reader != nil {
+			_, ok := part.reader.(io.Seeker)
+			rewindable = rewindable && ok
+		}
+		if size >= 0 {
+			if partSize := part.size(); partSize >= 0 {
+				size += partSize
+			} else {
+				size = -1
+			}
+		}
+	}
+	_ = mw.Close()
+
+	if size < 0 {
+		return -1, rewindable
+	}
+	return size + cw.n, rewindable
+}
+
+func (p *multipartPart) writeTo(w io.Writer) error {
+	switch {
+	case p.filePath != "":
+		f, err := os.Open(p.filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	case p.reader != nil:
+		if s, ok := p.reader.(io.Seeker); ok {
+			if _, err := s.Seek(p.offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		_, err := io.Copy(w, p.reader)
+		return err
+	default:
+		_, err := w.Write(p.data)
+		return err
+	}
+}
+
+// size returns the size of the part content, -1 if it's unknown.
+func (p *multipartPart) size() int64 {
+	switch {
+	case p.filePath != "":
+		fi, err := os.Stat(p.filePath)
+		if err != nil {
+			return -1
+		}
+		return fi.Size()
+	case p.reader != nil:
+		if s, ok := p.reader.(io.Seeker); ok {
+			end, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return -1
+			}
+			if _, err = s.Seek(p.offset, io.SeekStart); err != nil {
+				return -1
+			}
+			return end - p.offset
+		}
+		return readerSize(p.reader)
+	default:
+		return int64(len(p.data))
+	}
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if n > 0 {
+		b.transferred += int64(n)
+		if b.progress != nil {
+			b.progress(b.transferred, b.total)
+		}
+	}
+	return n, err
+}
+
+func (b *uploadBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		if b.closer != nil {
+			err = b.closer.Close()
+		}
+	})
+	return err
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// readerSize returns the unread size of r if it's known, otherwise -1.
+func readerSize(r io.Reader) int64 {
+	if v, ok := r.(interface{ Len() int }); ok {
+		return int64(v.Len())
+	}
+	return -1
+}
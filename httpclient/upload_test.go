@@ -0,0 +1,176 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	var (
+		ast      = assert.New(t)
+		requests int32
+		failures int32
+		bodySize int64
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, err := io.ReadAll(r.Body)
+		ast.NoError(err)
+		atomic.StoreInt64(&bodySize, int64(len(body)))
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		ast.NoError(err)
+		parts := map[string]string{}
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			ast.NoError(err)
+			bs, _ := io.ReadAll(p)
+			parts[p.FormName()+":"+p.FileName()] = string(bs)
+		}
+		_ = json.NewEncoder(w).Encode(parts)
+	}))
+	defer testServer.Close()
+
+	filePath := filepath.Join(t.TempDir(), "data.csv")
+	ast.NoError(os.WriteFile(filePath, []byte("a,b\n1,2\n"), 0o600))
+
+	reset := func(n int32) {
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&failures, n)
+	}
+
+	var transferred, total int64
+	c := NewObjectClient(testServer.URL)
+	policy := RetryPolicy{InitialInterval: time.Millisecond, RetryNonIdempotent: true}
+
+	reader := strings.NewReader("xxreader")
+	_, _ = reader.Seek(2, io.SeekStart)
+	opts := []RequestOption{
+		WithMultipartField("name", "graph"),
+		WithMultipartFile("file", filePath),
+		WithMultipartBytes("bytes", "bytes.txt", []byte("bytes")),
+		WithMultipartReader("reader", "reader.txt", reader),
+		WithUploadProgress(func(n, t int64) {
+			transferred, total = n, t
+		}),
+		WithRetry(policy),
+	}
+	expected := map[string]string{
+		"name:":             "graph",
+		"file:data.csv":     "a,b\n1,2\n",
+		"bytes:bytes.txt":   "bytes",
+		"reader:reader.txt": "reader",
+	}
+
+	reset(1)
+	var parts map[string]string
+	ast.NoError(c.Post("/", nil, &parts, opts...))
+	ast.Equal(expected, parts)
+	ast.EqualValues(2, atomic.LoadInt32(&requests))
+	ast.Equal(atomic.LoadInt64(&bodySize), total)
+	ast.Equal(total, transferred)
+
+	// the non-seekable reader is not retried
+	reset(1)
+	err := c.Post("/", nil, &parts,
+		WithMultipartReader("reader", "reader.txt", io.MultiReader(strings.NewReader("reader"))),
+		WithUploadProgress(func(n, t int64) {
+			transferred, total = n, t
+		}),
+		WithRetry(policy))
+	ast.True(IsResponseError(err, http.StatusServiceUnavailable))
+	ast.EqualValues(1, atomic.LoadInt32(&requests))
+	ast.Equal(int64(-1), total)
+
+	// the file is not found
+	reset(0)
+	err = c.Post("/", nil, &parts, WithMultipartFile("file", filepath.Join(t.TempDir(), "not-exists")))
+	ast.Error(err)
+}
+
+func TestWithUploadProgress(t *testing.T) {
+	ast := assert.New(t)
+
+	var contentLength int64
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.StoreInt64(&contentLength, r.ContentLength)
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer testServer.Close()
+
+	var transferred, total int64
+	c := NewBytesClient(testServer.URL, WithUploadProgress(func(n, t int64) {
+		transferred, total = n, t
+	}))
+
+	bodies := []interface{}{
+		[]byte("content"),
+		"content",
+		strings.NewReader("content"),
+	}
+	for _, body := range bodies {
+		transferred, total = 0, 0
+		bs, err := c.Put("/", body)
+		ast.NoError(err)
+		ast.Equal("content", string(bs))
+		ast.Equal(int64(7), transferred)
+		ast.Equal(int64(7), total)
+		ast.Equal(int64(7), atomic.LoadInt64(&contentLength))
+	}
+
+	transferred, total = 0, 0
+	bs, err := c.Put("/", []byte{})
+	ast.NoError(err)
+	ast.Empty(bs)
+	ast.Equal(int64(0), atomic.LoadInt64(&contentLength))
+
+	transferred, total = 0, 0
+	bs, err = c.Put("/", io.MultiReader(strings.NewReader("content")))
+	ast.NoError(err)
+	ast.Equal("content", string(bs))
+	ast.Equal(int64(7), transferred)
+	ast.Equal(int64(-1), total)
+	ast.Equal(int64(-1), atomic.LoadInt64(&contentLength))
+
+	// the body encoded by resty is not wrapped
+	transferred, total = 0, 0
+	bs, err = c.Put("/", map[string]string{"k": "v"})
+	ast.NoError(err)
+	ast.Equal(`{"k":"v"}`, string(bs))
+	ast.Equal(int64(0), transferred)
+}
+
+func TestRequestOptionsAppendMultipartPart(t *testing.T) {
+	ast := assert.New(t)
+
+	o := newRequestOptions(WithMultipartField("a", "1"))
+	o1 := o.WithOptions(WithMultipartField("b", "2"))
+	o2 := o.WithOptions(WithMultipartField("c", "3"))
+	ast.Len(o.multipartParts, 1)
+	ast.Len(o1.multipartParts, 2)
+	ast.Len(o2.multipartParts, 2)
+	ast.Equal("b", o1.multipartParts[1].fieldName)
+	ast.Equal("c", o2.multipartParts[1].fieldName)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultVerifySignatureMaxBodySize is the max size of the request body to verify.
+const DefaultVerifySignatureMaxBodySize = 10 * 1024 * 1024
+
+var errSignatureBodyTooLarge = errors.New("request body too large")
+
+type (
+	// SignatureVerifier verifies the signature of the request, such as the HMACSigner of httpclient.
+	SignatureVerifier interface {
+		Verify(r *http.Request) error
+	}
+
+	VerifySignatureConfig struct {
+		Skipper  Skipper
+		Verifier SignatureVerifier
+		// ErrorHandler handles the request which is failed to verify, default responds 401 with the error.
+		ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+		// MaxBodySize is the max size of the request body which is read to verify,
+		// default is DefaultVerifySignatureMaxBodySize, and the larger request is responded 413.
+		MaxBodySize int64
+	}
+
+	// limitedBody fails with errSignatureBodyTooLarge once more than n bytes are read.
+	limitedBody struct {
+		io.ReadCloser
+		n int64
+	}
+)
+
+// VerifySignature verifies the signature of the requests, the body can still be read by the next handler.
+func VerifySignature(config VerifySignatureConfig) func(next http.Handler) http.Handler {
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = defaultVerifySignatureErrorHandler
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultVerifySignatureMaxBodySize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Skipper(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > config.MaxBodySize {
+				http.Error(w, errSignatureBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &limitedBody{ReadCloser: r.Body, n: config.MaxBodySize}
+			}
+
+			if err := config.Verifier.Verify(r); err != nil {
+				if errors.Is(err, errSignatureBodyTooLarge) {
+					http.Error(w, errSignatureBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				config.ErrorHandler(w, r, err)
+				return
+			}
+			if body, ok := r.Body.(*limitedBody); ok { // the body is not read by the verifier
+				r.Body = body.ReadCloser
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n < 0 {
+		return 0, errSignatureBodyTooLarge
+	}
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n -= int64(n)
+	if b.n < 0 {
+		return n, errSignatureBodyTooLarge
+	}
+	return n, err
+}
+
+func defaultVerifySignatureErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	signer := httpclient.NewHMACSigner(httpclient.HMACSignerParams{Secret: []byte("secret")})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+
+	tests := []struct {
+		name       string
+		sign       bool
+		body       string
+		chunked    bool
+		config     VerifySignatureConfig
+		wantStatus int
+		wantBody   string
+	}{{
+		name:       "signed",
+		sign:       true,
+		body:       "body",
+		wantStatus: http.StatusOK,
+		wantBody:   "body",
+	}, {
+		name:       "unsigned",
+		body:       "body",
+		wantStatus: http.StatusUnauthorized,
+		wantBody:   httpclient.ErrSignatureMissing.Error(),
+	}, {
+		name: "unsigned:skipper",
+		body: "body",
+		config: VerifySignatureConfig{
+			Skipper: func(*http.Request) bool {
+				return true
+			},
+		},
+		wantStatus: http.StatusOK,
+		wantBody:   "body",
+	}, {
+		name: "unsigned:errorHandler",
+		body: "body",
+		config: VerifySignatureConfig{
+			ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+		},
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "tooLarge",
+		sign:       true,
+		body:       "body",
+		config:     VerifySignatureConfig{MaxBodySize: 3},
+		wantStatus: http.StatusRequestEntityTooLarge,
+		wantBody:   "request body too large",
+	}, {
+		name:       "tooLarge:chunked",
+		sign:       true,
+		body:       "body",
+		chunked:    true,
+		config:     VerifySignatureConfig{MaxBodySize: 3},
+		wantStatus: http.StatusRequestEntityTooLarge,
+		wantBody:   "request body too large",
+	}, {
+		name:       "maxBodySize",
+		sign:       true,
+		body:       "body",
+		chunked:    true,
+		config:     VerifySignatureConfig{MaxBodySize: 4},
+		wantStatus: http.StatusOK,
+		wantBody:   "body",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ast := assert.New(t)
+
+			req := httptest.NewRequest(http.MethodPost, "/path?q=v", strings.NewReader(test.body))
+			if test.sign {
+				ast.NoError(signer.Sign(req))
+			}
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+
+			config := test.config
+			config.Verifier = signer
+			VerifySignature(config)(next).ServeHTTP(rec, req)
+			ast.Equal(test.wantStatus, rec.Code)
+			ast.Equal(test.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package notify
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/vesoft-inc/go-pkg/httpclient"
 
@@ -29,6 +30,8 @@ type (
 		AtMobiles   []string
 		IsAtAll     bool
 		Title       string
+		// Secret signs the requests if it's not empty, see the security settings of the robot.
+		Secret string
 	}
 
 	dingTalkNotifier struct {
@@ -69,12 +72,28 @@ func NewWithDingTalks(configs ...DingTalkConfig) Notifier {
 
 func newDingTalkNotifier(config DingTalkConfig) StringNotifier { //nolint:gocritic
 	return &dingTalkNotifier{
-		client: httpclient.NewObjectClient(dingTalkRobotSendAddr, httpclient.WithQueryParam("access_token", config.AccessToken)),
+		client: httpclient.NewObjectClient(dingTalkRobotSendAddr, dingTalkOptions(config)...),
 		config: config,
 	}
 }
 
-func (n *dingTalkNotifier) Notify(_ context.Context, message string) error {
+func dingTalkOptions(config DingTalkConfig) []httpclient.RequestOption { //nolint:gocritic
+	opts := []httpclient.RequestOption{httpclient.WithQueryParam("access_token", config.AccessToken)}
+	if config.Secret != "" {
+		opts = append(opts, httpclient.WithSigner(httpclient.NewHMACSigner(httpclient.HMACSignerParams{
+			Secret:        []byte(config.Secret),
+			Location:      httpclient.SignatureInQuery,
+			SignatureName: "sign",
+			TimestampUnit: time.Millisecond,
+			Canonicalize: func(c *httpclient.CanonicalRequest) string {
+				return c.Timestamp + "\n" + config.Secret
+			},
+		})))
+	}
+	return opts
+}
+
+func (n *dingTalkNotifier) Notify(ctx context.Context, message string) error {
 	messageBody := &dingTalkMessage{
 		MsgType: string(n.config.MsgType),
 		At: dingTalkAtInfo{
@@ -98,7 +117,7 @@ func (n *dingTalkNotifier) Notify(_ context.Context, message string) error {
 		ErrMsg  string `json:"errmsg"`
 	}
 
-	if err := n.client.Post("", messageBody, &responseObj); err != nil {
+	if err := n.client.Post("", messageBody, &responseObj, httpclient.WithContext(ctx)); err != nil {
 		return err
 	}
 	if responseObj.ErrCode != 0 {
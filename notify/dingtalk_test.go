@@ -2,11 +2,16 @@ package notify
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/vesoft-inc/go-pkg/httpclient"
 
@@ -93,4 +98,34 @@ func TestDingTalkNotify(t *testing.T) {
 	if ast.ErrorIs(err, ErrNotifyNotification) {
 		ast.Contains(err.Error(), "500 Internal Server Error")
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = notifier.Notify(ctx, "Message")
+	if ast.ErrorIs(err, ErrNotifyNotification) {
+		ast.Contains(err.Error(), context.Canceled.Error())
+	}
+}
+
+func TestDingTalkNotifySign(t *testing.T) {
+	ast := assert.New(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		ast.Equal("token", query.Get("access_token"))
+		timestamp, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+		ast.NoError(err)
+		ast.InDelta(time.Now().UnixNano()/int64(time.Millisecond), timestamp, float64(time.Minute/time.Millisecond))
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(query.Get("timestamp") + "\nsecret"))
+		ast.Equal(base64.StdEncoding.EncodeToString(mac.Sum(nil)), query.Get("sign"))
+		_, _ = w.Write([]byte(`{"errcode": 0,"errmsg": "ok"}`))
+	}))
+	defer testServer.Close()
+
+	config := DingTalkConfig{AccessToken: "token", Secret: "secret", MsgType: DingDingMsgText}
+	n := newDingTalkNotifier(config)
+	n.(*dingTalkNotifier).client = httpclient.NewObjectClient(testServer.URL, dingTalkOptions(config)...)
+	ast.NoError(n.Notify(context.TODO(), "Message"))
 }
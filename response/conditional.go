@@ -0,0 +1,161 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// CachePolicy is used to generate the Cache-Control header.
+	CachePolicy struct {
+		Public         bool
+		Private        bool
+		NoCache        bool
+		NoStore        bool
+		MaxAge         time.Duration
+		SharedMaxAge   time.Duration
+		MustRevalidate bool
+		Immutable      bool
+	}
+
+	// CacheValidators are the validators of the data for conditional requests.
+	CacheValidators struct {
+		// ETag is the entity tag, it will be quoted if it's not.
+		ETag string
+		// LastModified is the last modified time of the data.
+		LastModified time.Time
+	}
+
+	standardHandlerDataCacheValidators struct {
+		data       interface{}
+		validators CacheValidators
+	}
+)
+
+// StandardHandlerDataWithCacheValidators attaches the cache validators to the data.
+// The ETag and Last-Modified headers are set by the validators instead of being computed,
+// and the data is rendered as same as without validators.
+// For examples:
+//
+//	return StandardHandlerDataWithCacheValidators(data, CacheValidators{
+//	    ETag:         strconv.FormatInt(data.Version, 10),
+//	    LastModified: data.UpdatedAt,
+//	})
+func StandardHandlerDataWithCacheValidators(data interface{}, validators CacheValidators) interface{} {
+	return &standardHandlerDataCacheValidators{data: data, validators: validators}
+}
+
+func GetStandardHandlerDataCacheValidators(data interface{}) (interface{}, *CacheValidators, bool) {
+	if v, ok := data.(*standardHandlerDataCacheValidators); ok {
+		return v.data, &v.validators, true
+	}
+	return data, nil, false
+}
+
+// String returns the value of Cache-Control header.
+func (p *CachePolicy) String() string {
+	var directives []string
+	if p.Public {
+		directives = append(directives, "public")
+	}
+	if p.Private {
+		directives = append(directives, "private")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.FormatInt(int64(p.MaxAge/time.Second), 10))
+	}
+	if p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.FormatInt(int64(p.SharedMaxAge/time.Second), 10))
+	}
+	if p.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// writeCacheHeaders writes the cache headers, and returns true if the response is not modified.
+func (h *standardHandler) writeCacheHeaders(w http.ResponseWriter, r *http.Request, validators *CacheValidators, bs []byte) bool {
+	if r == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	if h.params.GetCachePolicy != nil {
+		if policy := h.params.GetCachePolicy(r); policy != nil {
+			if cacheControl := policy.String(); cacheControl != "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+		}
+	}
+
+	var (
+		etag         string
+		lastModified time.Time
+	)
+	if validators != nil {
+		etag = quoteETag(validators.ETag)
+		lastModified = validators.LastModified
+	}
+	if etag == "" && h.params.ETag {
+		sum := sha256.Sum256(bs)
+		etag = quoteETag(base64.RawURLEncoding.EncodeToString(sum[:]))
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	return isNotModified(r, etag, lastModified)
+}
+
+// isNotModified evaluates the If-None-Match and If-Modified-Since, see RFC 7232.
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etag == "" {
+			return false
+		}
+		for _, v := range strings.Split(ifNoneMatch, ",") {
+			v = strings.TrimSpace(v)
+			if v == "*" || weakETag(v) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
@@ -0,0 +1,244 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachePolicy(t *testing.T) {
+	tests := []struct {
+		policy   CachePolicy
+		expected string
+	}{{
+		policy:   CachePolicy{},
+		expected: "",
+	}, {
+		policy:   CachePolicy{Private: true, NoCache: true},
+		expected: "private, no-cache",
+	}, {
+		policy:   CachePolicy{NoStore: true},
+		expected: "no-store",
+	}, {
+		policy: CachePolicy{
+			Public:         true,
+			MaxAge:         time.Minute,
+			SharedMaxAge:   time.Hour,
+			MustRevalidate: true,
+			Immutable:      true,
+		},
+		expected: "public, max-age=60, s-maxage=3600, must-revalidate, immutable",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.String())
+		})
+	}
+}
+
+func TestStandardHandlerConditional(t *testing.T) {
+	lastModified := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastModifiedHeader := "Sun, 02 Jan 2022 03:04:05 GMT"
+	sum := sha256.Sum256([]byte(`{"code":0,"data":"data","message":"Success"}`))
+	computedETag := `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+
+	tests := []struct {
+		name                 string
+		params               StandardHandlerParams
+		method               string
+		headers              map[string]string
+		data                 interface{}
+		err                  error
+		expectedStatus       int
+		expectedETag         string
+		expectedLastModified string
+		expectedCacheControl string
+		expectedBody         string
+	}{{
+		name:           "disabled",
+		method:         http.MethodGet,
+		data:           "data",
+		expectedStatus: 200,
+		expectedBody:   `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:           "etag:computed",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodGet,
+		data:           "data",
+		expectedStatus: 200,
+		expectedETag:   computedETag,
+		expectedBody:   `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:           "etag:computed:notModified",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodGet,
+		headers:        map[string]string{"If-None-Match": `"other", W/` + computedETag},
+		data:           "data",
+		expectedStatus: 304,
+		expectedETag:   computedETag,
+	}, {
+		name:           "etag:computed:modified",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodGet,
+		headers:        map[string]string{"If-None-Match": `"other"`},
+		data:           "data",
+		expectedStatus: 200,
+		expectedETag:   computedETag,
+		expectedBody:   `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:           "etag:computed:star",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodHead,
+		headers:        map[string]string{"If-None-Match": `*`},
+		data:           "data",
+		expectedStatus: 304,
+		expectedETag:   computedETag,
+	}, {
+		name:           "etag:computed:post",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodPost,
+		headers:        map[string]string{"If-None-Match": computedETag},
+		data:           "data",
+		expectedStatus: 200,
+		expectedBody:   `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:           "etag:computed:error",
+		params:         StandardHandlerParams{ETag: true},
+		method:         http.MethodGet,
+		headers:        map[string]string{"If-None-Match": `*`},
+		err:            errors.New("testError"),
+		expectedStatus: 500,
+		expectedBody:   `{"code":50000000,"message":"ErrInternalServer"}`,
+	}, {
+		name:   "validators",
+		method: http.MethodGet,
+		data: StandardHandlerDataWithCacheValidators(StandardHandlerDataFieldAny("data"), CacheValidators{
+			ETag:         "v1",
+			LastModified: lastModified,
+		}),
+		expectedStatus:       200,
+		expectedETag:         `"v1"`,
+		expectedLastModified: lastModifiedHeader,
+		expectedBody:         `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:    "validators:etag:notModified",
+		params:  StandardHandlerParams{ETag: true},
+		method:  http.MethodGet,
+		headers: map[string]string{"If-None-Match": `"v1"`},
+		data: StandardHandlerDataWithCacheValidators("data", CacheValidators{
+			ETag: `"v1"`,
+		}),
+		expectedStatus: 304,
+		expectedETag:   `"v1"`,
+	}, {
+		name:   "validators:etag:precedence",
+		method: http.MethodGet,
+		headers: map[string]string{
+			"If-None-Match":     `"v0"`,
+			"If-Modified-Since": lastModifiedHeader,
+		},
+		data: StandardHandlerDataWithCacheValidators("data", CacheValidators{
+			ETag:         "v1",
+			LastModified: lastModified,
+		}),
+		expectedStatus:       200,
+		expectedETag:         `"v1"`,
+		expectedLastModified: lastModifiedHeader,
+		expectedBody:         `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:    "validators:lastModified:notModified",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-Modified-Since": lastModifiedHeader},
+		data: StandardHandlerDataWithCacheValidators("data", CacheValidators{
+			LastModified: lastModified.Add(time.Millisecond),
+		}),
+		expectedStatus:       304,
+		expectedLastModified: lastModifiedHeader,
+	}, {
+		name:    "validators:lastModified:modified",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-Modified-Since": lastModifiedHeader},
+		data: StandardHandlerDataWithCacheValidators("data", CacheValidators{
+			LastModified: lastModified.Add(time.Second),
+		}),
+		expectedStatus:       200,
+		expectedLastModified: "Sun, 02 Jan 2022 03:04:06 GMT",
+		expectedBody:         `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name:    "validators:lastModified:invalid",
+		method:  http.MethodGet,
+		headers: map[string]string{"If-Modified-Since": "invalid"},
+		data: StandardHandlerDataWithCacheValidators(nil, CacheValidators{
+			LastModified: lastModified,
+		}),
+		expectedStatus:       200,
+		expectedLastModified: lastModifiedHeader,
+		expectedBody:         `{"code":0,"message":"Success"}`,
+	}, {
+		name: "cachePolicy",
+		params: StandardHandlerParams{
+			GetCachePolicy: func(r *http.Request) *CachePolicy {
+				return &CachePolicy{Private: true, MaxAge: 10 * time.Second}
+			},
+		},
+		method:               http.MethodGet,
+		data:                 "data",
+		expectedStatus:       200,
+		expectedCacheControl: "private, max-age=10",
+		expectedBody:         `{"code":0,"data":"data","message":"Success"}`,
+	}, {
+		name: "cachePolicy:nil",
+		params: StandardHandlerParams{
+			GetCachePolicy: func(r *http.Request) *CachePolicy {
+				return nil
+			},
+		},
+		method:         http.MethodGet,
+		data:           "data",
+		expectedStatus: 200,
+		expectedBody:   `{"code":0,"data":"data","message":"Success"}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ast := assert.New(t)
+
+			r := httptest.NewRequest(test.method, "http://localhost", nil)
+			for k, v := range test.headers {
+				r.Header.Set(k, v)
+			}
+
+			rec := httptest.NewRecorder()
+			NewStandardHandler(test.params).Handle(rec, r, test.data, test.err)
+			ast.Equal(test.expectedStatus, rec.Code)
+			ast.Equal(test.expectedETag, rec.Header().Get("ETag"))
+			ast.Equal(test.expectedLastModified, rec.Header().Get("Last-Modified"))
+			ast.Equal(test.expectedCacheControl, rec.Header().Get("Cache-Control"))
+			if test.expectedBody == "" {
+				ast.Equal("", rec.Body.String())
+			} else {
+				ast.JSONEq(test.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetStandardHandlerDataCacheValidators(t *testing.T) {
+	data, validators, ok := GetStandardHandlerDataCacheValidators("data")
+	assert.Equal(t, "data", data)
+	assert.Nil(t, validators)
+	assert.False(t, ok)
+
+	data, validators, ok = GetStandardHandlerDataCacheValidators(
+		StandardHandlerDataWithCacheValidators("data", CacheValidators{ETag: "v1"}))
+	assert.Equal(t, "data", data)
+	assert.Equal(t, &CacheValidators{ETag: "v1"}, validators)
+	assert.True(t, ok)
+}
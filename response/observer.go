@@ -0,0 +1,67 @@
+package response
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// UnmatchedRoute is the Route of the Observation if the route pattern is unknown.
+const UnmatchedRoute = "unmatched"
+
+var _ Observer = ObserverFunc(nil)
+
+type (
+	// Observer is used to observe the responses handled by Handler.
+	Observer interface {
+		// Observe is called after the response is written.
+		Observe(ctx context.Context, o *Observation)
+	}
+
+	// ObserverFunc is an adapter to allow the use of ordinary functions as Observer.
+	ObserverFunc func(ctx context.Context, o *Observation)
+
+	// Observation is the observation of one handled response.
+	Observation struct {
+		// Request is the handled request, it can be nil.
+		Request *http.Request
+		// Route is the route pattern of the request such as /users/{id}, default is UnmatchedRoute.
+		// It's used as a metric label, so it must not be the raw url path with unbounded values.
+		Route string
+		// Method is the http method of the request.
+		Method string
+		// HTTPStatus is the http status written.
+		HTTPStatus int
+		// Code is the errorx code, 0 indicates success.
+		Code int
+		// CategoryCode is the errorx category code, 0 indicates success.
+		CategoryCode int
+		// Latency is the duration since the start time in context, 0 if there is no start time.
+		Latency time.Duration
+		// ResponseBytes is the number of body bytes written.
+		ResponseBytes int
+		// Err is the error to handle.
+		Err error
+	}
+
+	startTimeCtxKey struct{}
+)
+
+// WithStartTime returns a copy of ctx with the start time of the request,
+// which is used to calculate the latency of the Observation.
+func WithStartTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, startTimeCtxKey{}, t)
+}
+
+// GetStartTime returns the start time of the request in ctx.
+func GetStartTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(startTimeCtxKey{}).(time.Time)
+	return t, ok
+}
+
+func (f ObserverFunc) Observe(ctx context.Context, o *Observation) {
+	if f == nil {
+		return
+	}
+	f(ctx, o)
+}
@@ -0,0 +1,147 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartTime(t *testing.T) {
+	ast := assert.New(t)
+
+	startTime, ok := GetStartTime(context.Background())
+	ast.False(ok)
+	ast.True(startTime.IsZero())
+
+	now := time.Now()
+	startTime, ok = GetStartTime(WithStartTime(context.Background(), now))
+	ast.True(ok)
+	ast.Equal(now, startTime)
+}
+
+func TestObserverFunc(t *testing.T) {
+	assert.NotPanics(t, func() {
+		ObserverFunc(nil).Observe(context.Background(), &Observation{})
+	})
+
+	var actual *Observation
+	ObserverFunc(func(_ context.Context, o *Observation) {
+		actual = o
+	}).Observe(context.Background(), &Observation{Code: 1})
+	assert.Equal(t, &Observation{Code: 1}, actual)
+}
+
+func TestStandardHandlerObserver(t *testing.T) {
+	testErr := errors.New("testError")
+	tests := []struct {
+		name             string
+		getRoute         func(r *http.Request) string
+		r                *http.Request
+		withStartTime    bool
+		data             interface{}
+		err              error
+		expectedRoute    string
+		expectedMethod   string
+		expectedStatus   int
+		expectedCode     int
+		expectedCategory int
+		expectedBytes    int
+	}{{
+		name:           "success",
+		r:              httptest.NewRequest("GET", "http://localhost/users/1", nil),
+		data:           "data",
+		expectedRoute:  UnmatchedRoute,
+		expectedMethod: "GET",
+		expectedStatus: 200,
+		expectedBytes:  len(`{"code":0,"data":"data","message":"Success"}`),
+	}, {
+		name: "success:route",
+		getRoute: func(r *http.Request) string {
+			return "/users/{id}"
+		},
+		r:              httptest.NewRequest("GET", "http://localhost/users/1", nil),
+		withStartTime:  true,
+		expectedRoute:  "/users/{id}",
+		expectedMethod: "GET",
+		expectedStatus: 200,
+		expectedBytes:  len(`{"code":0,"message":"Success"}`),
+	}, {
+		name:             "error",
+		r:                httptest.NewRequest("POST", "http://localhost/users", nil),
+		err:              errorx.WithCode(errorx.NewErrCode(403, 1, 2, "testError"), testErr),
+		expectedRoute:    UnmatchedRoute,
+		expectedMethod:   "POST",
+		expectedStatus:   403,
+		expectedCode:     40301002,
+		expectedCategory: 403,
+		expectedBytes:    len(`{"code":40301002,"message":"testError"}`),
+	}, {
+		name:             "error:internal",
+		r:                httptest.NewRequest("POST", "http://localhost/users", nil),
+		err:              testErr,
+		expectedRoute:    UnmatchedRoute,
+		expectedMethod:   "POST",
+		expectedStatus:   500,
+		expectedCode:     50000000,
+		expectedCategory: 500,
+		expectedBytes:    len(`{"code":50000000,"message":"ErrInternalServer"}`),
+	}, {
+		name:           "data:unsupported:type",
+		r:              httptest.NewRequest("GET", "http://localhost/users", nil),
+		data:           complex(0, 0),
+		expectedRoute:  UnmatchedRoute,
+		expectedMethod: "GET",
+		expectedStatus: 500,
+	}, {
+		name:           "r:nil",
+		expectedRoute:  UnmatchedRoute,
+		expectedStatus: 200,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ast := assert.New(t)
+
+			var observations []*Observation
+			h := NewStandardHandler(StandardHandlerParams{
+				GetRoute: test.getRoute,
+				Observer: ObserverFunc(func(_ context.Context, o *Observation) {
+					observations = append(observations, o)
+				}),
+			})
+
+			r := test.r
+			if r != nil && test.withStartTime {
+				r = r.WithContext(WithStartTime(r.Context(), time.Now().Add(-time.Second)))
+			}
+
+			rec := httptest.NewRecorder()
+			h.Handle(rec, r, test.data, test.err)
+
+			if ast.Len(observations, 1) {
+				o := observations[0]
+				ast.Equal(r, o.Request)
+				ast.Equal(test.expectedRoute, o.Route)
+				ast.Equal(test.expectedMethod, o.Method)
+				ast.Equal(test.expectedStatus, o.HTTPStatus)
+				ast.Equal(rec.Code, o.HTTPStatus)
+				ast.Equal(test.expectedCode, o.Code)
+				ast.Equal(test.expectedCategory, o.CategoryCode)
+				ast.Equal(test.expectedBytes, o.ResponseBytes)
+				ast.Equal(test.err, o.Err)
+				if test.withStartTime {
+					ast.GreaterOrEqual(o.Latency, time.Second)
+				} else {
+					ast.Zero(o.Latency)
+				}
+			}
+		})
+	}
+}
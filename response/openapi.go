@@ -0,0 +1,514 @@
+package response
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	openAPIVersion         = "3.0.3"
+	openAPIContentTypeJSON = "application/json"
+)
+
+var (
+	openAPIPathParamRegexp = regexp.MustCompile(`{([^}]+)}`)
+
+	openAPITypeTime          = reflect.TypeOf(time.Time{})
+	openAPITypeDuration      = reflect.TypeOf(time.Duration(0))
+	openAPITypeRawMessage    = reflect.TypeOf(json.RawMessage(nil))
+	openAPITypeJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	openAPITypeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type (
+	OpenAPIParams struct {
+		Title       string
+		Version     string
+		Description string
+		Servers     []string
+		// DetailsType is the same as StandardHandlerParams.DetailsType, the details field is documented if it's not none.
+		DetailsType StandardHandlerDetailsType
+	}
+
+	// OpenAPIEndpoint describes an endpoint handled by the standard Handler.
+	OpenAPIEndpoint struct {
+		Method      string
+		Path        string // the path template, for example, /users/{id}
+		Summary     string
+		Description string
+		OperationID string
+		Tags        []string
+		// Request is a sample of the request, the fields with `path` or `query` tag are the parameters,
+		// and the others are the json body.
+		Request interface{}
+		// Response is a sample of the data passed to Handler, it follows the same unwrapping rules,
+		// such as StandardHandlerDataFieldAny.
+		Response interface{}
+		// ErrCodes are the possible error codes of the endpoint.
+		ErrCodes []*errorx.ErrCode
+	}
+
+	OpenAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                       `json:"components"`
+	}
+
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	OpenAPIServer struct {
+		URL string `json:"url"`
+	}
+
+	OpenAPIOperation struct {
+		Summary     string                  `json:"summary,omitempty"`
+		Description string                  `json:"description,omitempty"`
+		OperationID string                  `json:"operationId,omitempty"`
+		Tags        []string                `json:"tags,omitempty"`
+		Parameters  []*OpenAPIParameter     `json:"parameters,omitempty"`
+		RequestBody *OpenAPIBody            `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIBody `json:"responses"`
+	}
+
+	OpenAPIParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"`
+		Required bool           `json:"required,omitempty"`
+		Schema   *OpenAPISchema `json:"schema"`
+	}
+
+	// OpenAPIBody is the request body or the response.
+	OpenAPIBody struct {
+		Description string                       `json:"description,omitempty"`
+		Required    bool                         `json:"required,omitempty"`
+		Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Description          string                    `json:"description,omitempty"`
+		Enum                 []interface{}             `json:"enum,omitempty"`
+		Minimum              *float64                  `json:"minimum,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	}
+
+	OpenAPIComponents struct {
+		Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+	}
+
+	openAPISchemaGenerator struct {
+		schemas map[string]*OpenAPISchema
+		names   map[reflect.Type]string
+		// expanding are the named structs being inlined, they're referenced in components on re-entry.
+		expanding map[reflect.Type]bool
+	}
+
+	openAPITagOptions struct {
+		hasComma  bool
+		omitempty bool
+		asString  bool
+	}
+)
+
+// NewOpenAPIDocument generates the OpenAPI 3 document of the endpoints.
+// The responses are wrapped in the envelope of the standard Handler, and the error codes are grouped by http status.
+func NewOpenAPIDocument(params OpenAPIParams, endpoints ...OpenAPIEndpoint) (*OpenAPIDocument, error) { //nolint:gocritic
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       params.Title,
+			Version:     params.Version,
+			Description: params.Description,
+		},
+		Paths: map[string]map[string]*OpenAPIOperation{},
+	}
+	for _, server := range params.Servers {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: server})
+	}
+
+	g := &openAPISchemaGenerator{
+		schemas:   map[string]*OpenAPISchema{},
+		names:     map[reflect.Type]string{},
+		expanding: map[reflect.Type]bool{},
+	}
+
+	for i := range endpoints {
+		e := &endpoints[i]
+		method := strings.ToLower(e.Method)
+		if doc.Paths[e.Path] == nil {
+			doc.Paths[e.Path] = map[string]*OpenAPIOperation{}
+		}
+		if _, ok := doc.Paths[e.Path][method]; ok {
+			return nil, errors.Errorf("duplicate endpoint %s %s", e.Method, e.Path)
+		}
+		doc.Paths[e.Path][method] = g.operation(&params, e)
+	}
+
+	if len(g.schemas) > 0 {
+		doc.Components.Schemas = g.schemas
+	}
+
+	return doc, nil
+}
+
+// ServeHTTP writes the document in json.
+func (d *OpenAPIDocument) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	bs, err := json.Marshal(d)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", openAPIContentTypeJSON)
+	_, _ = w.Write(bs)
+}
+
+func (g *openAPISchemaGenerator) operation(params *OpenAPIParams, e *OpenAPIEndpoint) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Summary:     e.Summary,
+		Description: e.Description,
+		OperationID: e.OperationID,
+		Tags:        e.Tags,
+		Responses:   map[string]*OpenAPIBody{},
+	}
+
+	pathParams := map[string]*OpenAPIParameter{}
+	for _, match := range openAPIPathParamRegexp.FindAllStringSubmatch(e.Path, -1) {
+		p := &OpenAPIParameter{Name: match[1], In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}}
+		pathParams[p.Name] = p
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if e.Request != nil {
+		body := g.requestParameters(op, pathParams, reflect.ValueOf(e.Request))
+		if body != nil && e.Method != http.MethodGet && e.Method != http.MethodHead {
+			op.RequestBody = &OpenAPIBody{
+				Required: true,
+				Content:  map[string]*OpenAPIMediaType{openAPIContentTypeJSON: {Schema: body}},
+			}
+		}
+	}
+
+	op.Responses[strconv.Itoa(http.StatusOK)] = &OpenAPIBody{
+		Description: "Success",
+		Content:     map[string]*OpenAPIMediaType{openAPIContentTypeJSON: {Schema: g.successEnvelope(e.Response)}},
+	}
+
+	statusErrCodes := map[int][]*errorx.ErrCode{}
+	for _, c := range e.ErrCodes {
+		statusErrCodes[c.GetHTTPStatus()] = append(statusErrCodes[c.GetHTTPStatus()], c)
+	}
+	for status, codes := range statusErrCodes {
+		op.Responses[strconv.Itoa(status)] = g.errorResponse(params, codes)
+	}
+
+	return op
+}
+
+// requestParameters adds the path and query parameters of the request, and returns the schema of body.
+func (g *openAPISchemaGenerator) requestParameters(
+	op *OpenAPIOperation, pathParams map[string]*OpenAPIParameter, v reflect.Value,
+) *OpenAPISchema {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Struct {
+		return g.schema(t, v)
+	}
+
+	body := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if name := f.Tag.Get("path"); name != "" {
+			p, ok := pathParams[name]
+			if !ok {
+				p = &OpenAPIParameter{Name: name, In: "path", Required: true}
+				pathParams[name] = p
+				op.Parameters = append(op.Parameters, p)
+			}
+			p.Schema = g.schema(f.Type, v.Field(i))
+			continue
+		}
+		if name := f.Tag.Get("query"); name != "" {
+			name, opts := parseOpenAPITag(name)
+			op.Parameters = append(op.Parameters, &OpenAPIParameter{
+				Name:     name,
+				In:       "query",
+				Required: !opts.omitempty && f.Type.Kind() != reflect.Ptr,
+				Schema:   g.schema(f.Type, v.Field(i)),
+			})
+			continue
+		}
+		g.structField(body, f, v.Field(i))
+	}
+	if len(body.Properties) == 0 {
+		return nil
+	}
+	return body
+}
+
+func (g *openAPISchemaGenerator) successEnvelope(response interface{}) *OpenAPISchema {
+	envelope := &OpenAPISchema{
+		Type:     "object",
+		Required: []string{standardHandlerFieldCode, standardHandlerFieldMessage},
+		Properties: map[string]*OpenAPISchema{
+			standardHandlerFieldCode:    {Type: "integer", Enum: []interface{}{0}},
+			standardHandlerFieldMessage: {Type: "string", Enum: []interface{}{"Success"}},
+		},
+	}
+	// the same as standardHandler.getData
+	if data := (*standardHandler)(nil).getData(response); data != nil {
+		v := reflect.ValueOf(data)
+		envelope.Properties[standardHandlerFieldData] = g.schema(v.Type(), v)
+	}
+	return envelope
+}
+
+func (*openAPISchemaGenerator) errorResponse(params *OpenAPIParams, codes []*errorx.ErrCode) *OpenAPIBody {
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].GetCode() < codes[j].GetCode()
+	})
+
+	var (
+		codeEnum     []interface{}
+		messageEnum  []interface{}
+		descriptions []string
+	)
+	for _, c := range codes {
+		codeEnum = append(codeEnum, c.GetCode())
+		messageEnum = append(messageEnum, c.GetMessage())
+		descriptions = append(descriptions, fmt.Sprintf("%d: %s", c.GetCode(), c.GetMessage()))
+	}
+
+	envelope := &OpenAPISchema{
+		Type:     "object",
+		Required: []string{standardHandlerFieldCode, standardHandlerFieldMessage},
+		Properties: map[string]*OpenAPISchema{
+			standardHandlerFieldCode:    {Type: "integer", Enum: codeEnum},
+			standardHandlerFieldMessage: {Type: "string", Enum: messageEnum},
+		},
+	}
+	if params.DetailsType != StandardHandlerDetailsNone {
+		envelope.Properties[standardHandlerFieldDetails] = &OpenAPISchema{Type: "string"}
+	}
+
+	return &OpenAPIBody{
+		Description: strings.Join(descriptions, "\n"),
+		Content:     map[string]*OpenAPIMediaType{openAPIContentTypeJSON: {Schema: envelope}},
+	}
+}
+
+// schema generates the schema of type t, v is the sample value which can be invalid.
+// The dynamic types of the interface fields in sample are used.
+func (g *openAPISchemaGenerator) schema(t reflect.Type, v reflect.Value) *OpenAPISchema {
+	if t.Kind() == reflect.Interface {
+		if v.IsValid() && !v.IsNil() {
+			return g.schema(v.Elem().Type(), v.Elem())
+		}
+		return &OpenAPISchema{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+		return g.schema(t.Elem(), v)
+	}
+
+	switch t {
+	case openAPITypeTime:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case openAPITypeDuration:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case openAPITypeRawMessage:
+		return &OpenAPISchema{}
+	}
+	if t.Implements(openAPITypeJSONMarshaler) || reflect.PtrTo(t).Implements(openAPITypeJSONMarshaler) {
+		return &OpenAPISchema{}
+	}
+	if t.Implements(openAPITypeTextMarshaler) || reflect.PtrTo(t).Implements(openAPITypeTextMarshaler) {
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := float64(0)
+		return &OpenAPISchema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		var elem reflect.Value
+		if v.IsValid() && v.Len() > 0 {
+			elem = v.Index(0)
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem(), elem)}
+	case reflect.Map:
+		var elem reflect.Value
+		if v.IsValid() && v.Len() > 0 {
+			elem = v.MapIndex(v.MapKeys()[0])
+		}
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem(), elem)}
+	case reflect.Struct:
+		return g.structSchema(t, v)
+	}
+	return &OpenAPISchema{}
+}
+
+// structSchema generates the schema of struct, the named struct without interface fields is referenced in components.
+// The named struct with interface fields is inlined, and referenced if it's recursive.
+func (g *openAPISchemaGenerator) structSchema(t reflect.Type, v reflect.Value) *OpenAPISchema {
+	if t.Name() == "" {
+		return g.structObject(t, v)
+	}
+	if hasInterfaceField(t) && !g.expanding[t] {
+		g.expanding[t] = true
+		defer delete(g.expanding, t)
+		return g.structObject(t, v)
+	}
+
+	if name, ok := g.names[t]; ok {
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := t.Name()
+	if _, ok := g.schemas[name]; ok {
+		name = strings.ReplaceAll(t.String(), ".", "_")
+		for i := 2; ; i++ {
+			if _, ok = g.schemas[name]; !ok {
+				break
+			}
+			name = fmt.Sprintf("%s_%d", strings.ReplaceAll(t.String(), ".", "_"), i)
+		}
+	}
+
+	g.names[t] = name
+	g.schemas[name] = &OpenAPISchema{} // placeholder for recursive types
+	g.schemas[name] = g.structObject(t, reflect.Value{})
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *openAPISchemaGenerator) structObject(t reflect.Type, v reflect.Value) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		g.structField(s, t.Field(i), fv)
+	}
+	return s
+}
+
+func (g *openAPISchemaGenerator) structField(s *OpenAPISchema, f reflect.StructField, v reflect.Value) {
+	name, opts := parseOpenAPITag(f.Tag.Get("json"))
+	if name == "-" && !opts.hasComma {
+		return
+	}
+
+	if f.Anonymous && name == "" {
+		t := f.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			if v.IsValid() {
+				v = v.Elem()
+			}
+		}
+		if t.Kind() == reflect.Struct {
+			embedded := g.structObject(t, v)
+			for k, p := range embedded.Properties {
+				s.Properties[k] = p
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			return
+		}
+	}
+
+	if f.PkgPath != "" {
+		return
+	}
+	if name == "" {
+		name = f.Name
+	}
+
+	fieldSchema := g.schema(f.Type, v)
+	if opts.asString {
+		fieldSchema = &OpenAPISchema{Type: "string"}
+	}
+	s.Properties[name] = fieldSchema
+	if !opts.omitempty {
+		s.Required = append(s.Required, name)
+	}
+}
+
+func parseOpenAPITag(tag string) (name string, opts openAPITagOptions) {
+	parts := strings.Split(tag, ",")
+	opts.hasComma = len(parts) > 1
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			opts.omitempty = true
+		case "string":
+			opts.asString = true
+		}
+	}
+	return parts[0], opts
+}
+
+func hasInterfaceField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array || ft.Kind() == reflect.Map {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Interface {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	testOpenAPIUser struct {
+		ID        int64              `json:"id"`
+		Name      string             `json:"name"`
+		Email     *string            `json:"email,omitempty"`
+		Tags      []string           `json:"tags"`
+		Labels    map[string]float64 `json:"labels,omitempty"`
+		Avatar    []byte             `json:"avatar,omitempty"`
+		Parent    *testOpenAPIUser   `json:"parent,omitempty"`
+		CreatedAt time.Time          `json:"createdAt"`
+		Version   uint32             `json:"version,string"`
+		Ignored   string             `json:"-"`
+		internal  string
+	}
+
+	testOpenAPIGetUserRequest struct {
+		ID      int64 `path:"id"`
+		Verbose bool  `query:"verbose,omitempty"`
+	}
+
+	testOpenAPICreateUserRequest struct {
+		testOpenAPIBase
+		Space string `query:"space"`
+		Name  string `json:"name"`
+	}
+
+	testOpenAPIBase struct {
+		RequestID string `json:"requestId,omitempty"`
+	}
+)
+
+func TestNewOpenAPIDocument(t *testing.T) {
+	ast := assert.New(t)
+
+	errParam := errorx.NewErrCode(errorx.CCBadRequest, 1, 1, "ErrParam")
+	errInvalidName := errorx.NewErrCode(errorx.CCBadRequest, 1, 2, "ErrInvalidName")
+	errNotFound := errorx.NewErrCode(errorx.CCNotFound, 1, 0, "ErrNotFound")
+
+	doc, err := NewOpenAPIDocument(OpenAPIParams{
+		Title:       "test",
+		Version:     "v1",
+		Servers:     []string{"http://localhost"},
+		DetailsType: StandardHandlerDetailsNormal,
+	}, OpenAPIEndpoint{
+		Method:      http.MethodGet,
+		Path:        "/users/{id}",
+		Summary:     "get user",
+		OperationID: "getUser",
+		Tags:        []string{"user"},
+		Request:     &testOpenAPIGetUserRequest{},
+		Response:    &testOpenAPIUser{},
+		ErrCodes:    []*errorx.ErrCode{errNotFound, errParam},
+	}, OpenAPIEndpoint{
+		Method:   http.MethodPost,
+		Path:     "/users",
+		Request:  testOpenAPICreateUserRequest{},
+		Response: StandardHandlerDataFieldAny(&testOpenAPIUser{}),
+		ErrCodes: []*errorx.ErrCode{errInvalidName, errParam},
+	}, OpenAPIEndpoint{
+		Method: http.MethodGet,
+		Path:   "/users",
+		Response: struct{ Data interface{} }{
+			Data: StandardHandlerDataFieldAny(NewOffsetPage([]testOpenAPIUser{}, 0, &PageQuery{})),
+		},
+	}, OpenAPIEndpoint{
+		Method: http.MethodDelete,
+		Path:   "/users/{id}",
+	})
+	if !ast.NoError(err) {
+		return
+	}
+
+	bs, err := json.Marshal(doc)
+	ast.NoError(err)
+
+	envelope := func(data string) string {
+		if data == "" {
+			return `{"type":"object","required":["code","message"],"properties":{
+				"code":{"type":"integer","enum":[0]},
+				"message":{"type":"string","enum":["Success"]}}}`
+		}
+		return `{"type":"object","required":["code","message"],"properties":{
+			"code":{"type":"integer","enum":[0]},
+			"message":{"type":"string","enum":["Success"]},
+			"data":` + data + `}}`
+	}
+
+	ast.JSONEq(`{
+		"openapi": "3.0.3",
+		"info": {"title": "test", "version": "v1"},
+		"servers": [{"url": "http://localhost"}],
+		"paths": {
+			"/users/{id}": {
+				"get": {
+					"summary": "get user",
+					"operationId": "getUser",
+					"tags": ["user"],
+					"parameters": [
+						{"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}},
+						{"name": "verbose", "in": "query", "schema": {"type": "boolean"}}
+					],
+					"responses": {
+						"200": {"description": "Success", "content": {"application/json": {"schema": `+
+		envelope(`{"$ref": "#/components/schemas/testOpenAPIUser"}`)+`}}},
+						"400": {"description": "40001001: ErrParam", "content": {"application/json": {"schema": {
+							"type": "object", "required": ["code", "message"], "properties": {
+								"code": {"type": "integer", "enum": [40001001]},
+								"message": {"type": "string", "enum": ["ErrParam"]},
+								"details": {"type": "string"}
+							}
+						}}}},
+						"404": {"description": "40401000: ErrNotFound", "content": {"application/json": {"schema": {
+							"type": "object", "required": ["code", "message"], "properties": {
+								"code": {"type": "integer", "enum": [40401000]},
+								"message": {"type": "string", "enum": ["ErrNotFound"]},
+								"details": {"type": "string"}
+							}
+						}}}}
+					}
+				},
+				"delete": {
+					"parameters": [
+						{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}
+					],
+					"responses": {
+						"200": {"description": "Success", "content": {"application/json": {"schema": `+envelope("")+`}}}
+					}
+				}
+			},
+			"/users": {
+				"post": {
+					"parameters": [
+						{"name": "space", "in": "query", "required": true, "schema": {"type": "string"}}
+					],
+					"requestBody": {"required": true, "content": {"application/json": {"schema": {
+						"type": "object",
+						"required": ["name"],
+						"properties": {
+							"requestId": {"type": "string"},
+							"name": {"type": "string"}
+						}
+					}}}},
+					"responses": {
+						"200": {"description": "Success", "content": {"application/json": {"schema": `+
+		envelope(`{"$ref": "#/components/schemas/testOpenAPIUser"}`)+`}}},
+						"400": {"description": "40001001: ErrParam\n40001002: ErrInvalidName", "content": {"application/json": {"schema": {
+							"type": "object", "required": ["code", "message"], "properties": {
+								"code": {"type": "integer", "enum": [40001001, 40001002]},
+								"message": {"type": "string", "enum": ["ErrParam", "ErrInvalidName"]},
+								"details": {"type": "string"}
+							}
+						}}}}
+					}
+				},
+				"get": {
+					"responses": {
+						"200": {"description": "Success", "content": {"application/json": {"schema": `+
+		envelope(`{
+			"type": "object",
+			"required": ["items", "total", "page", "pageSize"],
+			"properties": {
+				"items": {"type": "array", "items": {"$ref": "#/components/schemas/testOpenAPIUser"}},
+				"total": {"type": "integer", "format": "int64"},
+				"page": {"type": "integer", "format": "int64"},
+				"pageSize": {"type": "integer", "format": "int64"}
+			}
+		}`)+`}}}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"testOpenAPIUser": {
+					"type": "object",
+					"required": ["id", "name", "tags", "createdAt", "version"],
+					"properties": {
+						"id": {"type": "integer", "format": "int64"},
+						"name": {"type": "string"},
+						"email": {"type": "string"},
+						"tags": {"type": "array", "items": {"type": "string"}},
+						"labels": {"type": "object", "additionalProperties": {"type": "number", "format": "double"}},
+						"avatar": {"type": "string", "format": "byte"},
+						"parent": {"$ref": "#/components/schemas/testOpenAPIUser"},
+						"createdAt": {"type": "string", "format": "date-time"},
+						"version": {"type": "string"}
+					}
+				}
+			}
+		}
+	}`, string(bs))
+
+	rec := httptest.NewRecorder()
+	doc.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/openapi.json", nil))
+	ast.Equal(http.StatusOK, rec.Code)
+	ast.Equal("application/json", rec.Header().Get("Content-Type"))
+	ast.JSONEq(string(bs), rec.Body.String())
+}
+
+func TestNewOpenAPIDocumentDuplicate(t *testing.T) {
+	doc, err := NewOpenAPIDocument(OpenAPIParams{}, OpenAPIEndpoint{
+		Method: http.MethodGet,
+		Path:   "/users",
+	}, OpenAPIEndpoint{
+		Method: http.MethodGet,
+		Path:   "/users",
+	})
+	assert.Nil(t, doc)
+	assert.EqualError(t, err, "duplicate endpoint GET /users")
+}
+
+func TestOpenAPISchemaNameConflict(t *testing.T) {
+	type testOpenAPIUser struct {
+		Name string `json:"name"`
+	}
+
+	doc, err := NewOpenAPIDocument(OpenAPIParams{}, OpenAPIEndpoint{
+		Method:   http.MethodGet,
+		Path:     "/a",
+		Response: struct{ A, B interface{} }{A: testOpenAPIUser{}, B: []interface{}{uint8(1), 1.5, float32(1), nil}},
+	}, OpenAPIEndpoint{
+		Method:   http.MethodGet,
+		Path:     "/b",
+		Response: map[string]interface{}{"user": &testOpenAPIUser{}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, doc.Components.Schemas, 1)
+
+	doc, err = NewOpenAPIDocument(OpenAPIParams{}, OpenAPIEndpoint{
+		Method:   http.MethodGet,
+		Path:     "/a",
+		Response: testOpenAPIUser{},
+	}, OpenAPIEndpoint{
+		Method:   http.MethodGet,
+		Path:     "/b",
+		Response: json.RawMessage("{}"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &OpenAPISchema{}, doc.Paths["/b"]["get"].Responses["200"].Content["application/json"].Schema.Properties["data"])
+	assert.Equal(t, &OpenAPISchema{
+		Type:       "object",
+		Required:   []string{"name"},
+		Properties: map[string]*OpenAPISchema{"name": {Type: "string"}},
+	}, doc.Components.Schemas["testOpenAPIUser"])
+}
+
+func TestOpenAPISchemaRecursiveInterface(t *testing.T) {
+	type testOpenAPINode struct {
+		Value    interface{}        `json:"value"`
+		Children []*testOpenAPINode `json:"children"`
+	}
+
+	doc, err := NewOpenAPIDocument(OpenAPIParams{}, OpenAPIEndpoint{
+		Method:   http.MethodGet,
+		Path:     "/nodes",
+		Response: testOpenAPINode{Value: "v", Children: []*testOpenAPINode{{Value: 1}}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	data := doc.Paths["/nodes"]["get"].Responses["200"].Content["application/json"].Schema.Properties["data"]
+	assert.Equal(t, &OpenAPISchema{Type: "string"}, data.Properties["value"])
+	assert.Equal(t, "#/components/schemas/testOpenAPINode", data.Properties["children"].Items.Ref)
+	assert.Equal(t, &OpenAPISchema{
+		Type:     "object",
+		Required: []string{"value", "children"},
+		Properties: map[string]*OpenAPISchema{
+			"value":    {},
+			"children": {Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/testOpenAPINode"}},
+		},
+	}, doc.Components.Schemas["testOpenAPINode"])
+}
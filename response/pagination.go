@@ -0,0 +1,311 @@
+package response
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultPaginatorPageParam     = "page"
+	DefaultPaginatorPageSizeParam = "pageSize"
+	DefaultPaginatorCursorParam   = "cursor"
+	DefaultPaginatorPageSize      = 20
+	DefaultPaginatorMaxPageSize   = 1000
+	DefaultPaginatorMaxPage       = 1000000
+
+	paginatorCursorSignatureSeparator = "."
+)
+
+var (
+	_ Paginator = (*defaultPaginator)(nil)
+
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrPaginatorBadRequest is the default code of the invalid pagination query params.
+	ErrPaginatorBadRequest = errorx.NewErrCode(errorx.CCBadRequest, 0, 0, "ErrBadRequest")
+)
+
+type (
+	// Paginator parses the pagination query params and renders the pagination navigation.
+	Paginator interface {
+		// ParsePageQuery parses the page, page size and cursor from query params of r.
+		ParsePageQuery(r *http.Request) (*PageQuery, error)
+		// EncodeCursor encodes v into an opaque cursor token.
+		EncodeCursor(v interface{}) (string, error)
+		// DecodeCursor decodes the cursor token into v.
+		DecodeCursor(cursor string, v interface{}) error
+		// SetOffsetLinks sets the RFC 5988 Link header for an offset page.
+		SetOffsetLinks(w http.ResponseWriter, r *http.Request, page *OffsetPage)
+		// SetCursorLinks sets the RFC 5988 Link header for a cursor page.
+		SetCursorLinks(w http.ResponseWriter, r *http.Request, page *CursorPage)
+	}
+
+	PaginatorParams struct {
+		// PageParam is the query param of page number, default is DefaultPaginatorPageParam.
+		PageParam string
+		// PageSizeParam is the query param of page size, default is DefaultPaginatorPageSizeParam.
+		PageSizeParam string
+		// CursorParam is the query param of cursor, default is DefaultPaginatorCursorParam.
+		CursorParam string
+		// DefaultPageSize is used if page size is not set, default is DefaultPaginatorPageSize.
+		DefaultPageSize int
+		// MaxPageSize limits the page size, the larger one is reduced to it, default is DefaultPaginatorMaxPageSize.
+		MaxPageSize int
+		// MaxPage limits the page number, the larger one is invalid, default is DefaultPaginatorMaxPage.
+		// It's reduced so that the Offset of the max page never overflows.
+		MaxPage int
+		// CursorSecret is used to sign the cursor tokens via HMAC-SHA256, the tokens are only encoded if it's empty.
+		CursorSecret []byte
+		// ErrCode is the code of the invalid query params, default is ErrPaginatorBadRequest.
+		ErrCode *errorx.ErrCode
+	}
+
+	// PageQuery is the parsed pagination query params.
+	PageQuery struct {
+		Page     int
+		PageSize int
+		Cursor   string
+	}
+
+	// OffsetPage is the data of an offset pagination response.
+	OffsetPage struct {
+		Items    interface{} `json:"items"`
+		Total    int64       `json:"total"`
+		Page     int         `json:"page"`
+		PageSize int         `json:"pageSize"`
+	}
+
+	// CursorPage is the data of a cursor pagination response.
+	CursorPage struct {
+		Items      interface{} `json:"items"`
+		NextCursor string      `json:"nextCursor,omitempty"`
+		PrevCursor string      `json:"prevCursor,omitempty"`
+		HasMore    bool        `json:"hasMore"`
+	}
+
+	defaultPaginator struct {
+		params PaginatorParams
+	}
+)
+
+func NewPaginator(params PaginatorParams) Paginator { //nolint:gocritic
+	if params.PageParam == "" {
+		params.PageParam = DefaultPaginatorPageParam
+	}
+	if params.PageSizeParam == "" {
+		params.PageSizeParam = DefaultPaginatorPageSizeParam
+	}
+	if params.CursorParam == "" {
+		params.CursorParam = DefaultPaginatorCursorParam
+	}
+	if params.MaxPageSize <= 0 {
+		params.MaxPageSize = DefaultPaginatorMaxPageSize
+	}
+	if params.DefaultPageSize <= 0 {
+		params.DefaultPageSize = DefaultPaginatorPageSize
+	}
+	if params.DefaultPageSize > params.MaxPageSize {
+		params.DefaultPageSize = params.MaxPageSize
+	}
+	if params.MaxPage <= 0 {
+		params.MaxPage = DefaultPaginatorMaxPage
+	}
+	if maxPage := math.MaxInt32/params.MaxPageSize + 1; params.MaxPage > maxPage {
+		params.MaxPage = maxPage
+	}
+	if params.ErrCode == nil {
+		params.ErrCode = ErrPaginatorBadRequest
+	}
+	return &defaultPaginator{
+		params: params,
+	}
+}
+
+// NewOffsetPage creates an OffsetPage with the items of the page q.
+func NewOffsetPage(items interface{}, total int64, q *PageQuery) *OffsetPage {
+	return &OffsetPage{
+		Items:    normalizePageItems(items),
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}
+}
+
+// NewCursorPage creates a CursorPage, the HasMore is true if the nextCursor is not empty.
+func NewCursorPage(items interface{}, nextCursor, prevCursor string) *CursorPage {
+	return &CursorPage{
+		Items:      normalizePageItems(items),
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// Offset returns the number of items to skip, it's math.MaxInt if it overflows.
+func (q *PageQuery) Offset() int {
+	if q.Page <= 1 || q.PageSize <= 0 {
+		return 0
+	}
+	if q.Page-1 > math.MaxInt/q.PageSize {
+		return math.MaxInt
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// Limit returns the max number of items to return.
+func (q *PageQuery) Limit() int {
+	return q.PageSize
+}
+
+// TotalPages returns the number of pages.
+func (p *OffsetPage) TotalPages() int {
+	if p.PageSize <= 0 {
+		return 0
+	}
+	return int((p.Total + int64(p.PageSize) - 1) / int64(p.PageSize))
+}
+
+func (p *defaultPaginator) ParsePageQuery(r *http.Request) (*PageQuery, error) {
+	query := r.URL.Query()
+	q := &PageQuery{
+		Page:     1,
+		PageSize: p.params.DefaultPageSize,
+		Cursor:   query.Get(p.params.CursorParam),
+	}
+
+	if v := query.Get(p.params.PageParam); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 || page > p.params.MaxPage {
+			return nil, errorx.WithCode(p.params.ErrCode, err, "invalid %s %q", p.params.PageParam, v)
+		}
+		q.Page = page
+	}
+
+	if v := query.Get(p.params.PageSizeParam); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize < 1 {
+			return nil, errorx.WithCode(p.params.ErrCode, err, "invalid %s %q", p.params.PageSizeParam, v)
+		}
+		if pageSize > p.params.MaxPageSize {
+			pageSize = p.params.MaxPageSize
+		}
+		q.PageSize = pageSize
+	}
+
+	return q, nil
+}
+
+func (p *defaultPaginator) EncodeCursor(v interface{}) (string, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	cursor := base64.RawURLEncoding.EncodeToString(bs)
+	if len(p.params.CursorSecret) > 0 {
+		cursor += paginatorCursorSignatureSeparator + p.signCursor(cursor)
+	}
+	return cursor, nil
+}
+
+func (p *defaultPaginator) DecodeCursor(cursor string, v interface{}) error {
+	payload := cursor
+	if len(p.params.CursorSecret) > 0 {
+		index := strings.LastIndex(cursor, paginatorCursorSignatureSeparator)
+		if index < 0 {
+			return p.invalidCursorError(cursor)
+		}
+		payload = cursor[:index]
+		if !hmac.Equal([]byte(cursor[index+1:]), []byte(p.signCursor(payload))) {
+			return p.invalidCursorError(cursor)
+		}
+	}
+
+	bs, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return p.invalidCursorError(cursor)
+	}
+	if err = json.Unmarshal(bs, v); err != nil {
+		return p.invalidCursorError(cursor)
+	}
+	return nil
+}
+
+func (p *defaultPaginator) SetOffsetLinks(w http.ResponseWriter, r *http.Request, page *OffsetPage) {
+	var links []string
+	pageLink := func(n int, rel string) {
+		links = append(links, p.formatLink(r.URL, rel, map[string]string{
+			p.params.PageParam:     strconv.Itoa(n),
+			p.params.PageSizeParam: strconv.Itoa(page.PageSize),
+		}))
+	}
+
+	totalPages := page.TotalPages()
+	pageLink(1, "first")
+	if page.Page > 1 {
+		pageLink(page.Page-1, "prev")
+	}
+	if page.Page < totalPages {
+		pageLink(page.Page+1, "next")
+	}
+	if totalPages > 0 {
+		pageLink(totalPages, "last")
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func (p *defaultPaginator) SetCursorLinks(w http.ResponseWriter, r *http.Request, page *CursorPage) {
+	var links []string
+	if page.PrevCursor != "" {
+		links = append(links, p.formatLink(r.URL, "prev", map[string]string{p.params.CursorParam: page.PrevCursor}))
+	}
+	if page.NextCursor != "" {
+		links = append(links, p.formatLink(r.URL, "next", map[string]string{p.params.CursorParam: page.NextCursor}))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func (*defaultPaginator) formatLink(u *url.URL, rel string, params map[string]string) string {
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	link := url.URL{
+		Path:     u.Path,
+		RawQuery: query.Encode(),
+	}
+	return fmt.Sprintf("<%s>; rel=%q", link.String(), rel)
+}
+
+func (p *defaultPaginator) signCursor(payload string) string {
+	mac := hmac.New(sha256.New, p.params.CursorSecret)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (p *defaultPaginator) invalidCursorError(cursor string) error {
+	return errorx.WithCode(p.params.ErrCode, ErrInvalidCursor, "invalid %s %q", p.params.CursorParam, cursor)
+}
+
+// normalizePageItems makes the nil items to be rendered as an empty array.
+func normalizePageItems(items interface{}) interface{} {
+	if isInterfaceNil(items) {
+		return []interface{}{}
+	}
+	return items
+}
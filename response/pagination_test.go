@@ -0,0 +1,203 @@
+package response
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginatorParsePageQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    PaginatorParams
+		uri       string
+		expected  *PageQuery
+		expectErr bool
+	}{{
+		name:     "default",
+		uri:      "http://localhost/users",
+		expected: &PageQuery{Page: 1, PageSize: DefaultPaginatorPageSize},
+	}, {
+		name:     "page:pageSize:cursor",
+		uri:      "http://localhost/users?page=3&pageSize=10&cursor=abc",
+		expected: &PageQuery{Page: 3, PageSize: 10, Cursor: "abc"},
+	}, {
+		name:     "pageSize:max",
+		params:   PaginatorParams{MaxPageSize: 50},
+		uri:      "http://localhost/users?pageSize=100",
+		expected: &PageQuery{Page: 1, PageSize: 50},
+	}, {
+		name:     "defaultPageSize:max",
+		params:   PaginatorParams{DefaultPageSize: 100, MaxPageSize: 50},
+		uri:      "http://localhost/users",
+		expected: &PageQuery{Page: 1, PageSize: 50},
+	}, {
+		name: "custom:params",
+		params: PaginatorParams{
+			PageParam:     "p",
+			PageSizeParam: "size",
+			CursorParam:   "after",
+		},
+		uri:      "http://localhost/users?p=2&size=5&after=abc",
+		expected: &PageQuery{Page: 2, PageSize: 5, Cursor: "abc"},
+	}, {
+		name:      "page:invalid",
+		uri:       "http://localhost/users?page=a",
+		expectErr: true,
+	}, {
+		name:      "page:zero",
+		uri:       "http://localhost/users?page=0",
+		expectErr: true,
+	}, {
+		name:      "page:max",
+		params:    PaginatorParams{MaxPage: 10},
+		uri:       "http://localhost/users?page=11",
+		expectErr: true,
+	}, {
+		name:      "page:overflow",
+		uri:       "http://localhost/users?page=9223372036854775807",
+		expectErr: true,
+	}, {
+		name:      "pageSize:invalid",
+		uri:       "http://localhost/users?pageSize=-1",
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ast := assert.New(t)
+			p := NewPaginator(test.params)
+			q, err := p.ParsePageQuery(httptest.NewRequest("GET", test.uri, nil))
+			if test.expectErr {
+				ast.Nil(q)
+				e, ok := errorx.AsCodeError(err)
+				if ast.True(ok) {
+					ast.Equal(400, e.GetHTTPStatus())
+					ast.True(errorx.IsCodeError(err, ErrPaginatorBadRequest))
+				}
+				return
+			}
+			ast.NoError(err)
+			ast.Equal(test.expected, q)
+		})
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	q := &PageQuery{Page: 3, PageSize: 10}
+	assert.Equal(t, 20, q.Offset())
+	assert.Equal(t, 10, q.Limit())
+	assert.Equal(t, 0, (&PageQuery{}).Offset())
+	assert.Equal(t, math.MaxInt, (&PageQuery{Page: math.MaxInt, PageSize: 10}).Offset())
+}
+
+func TestPaginatorCursor(t *testing.T) {
+	type cursor struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	for _, secret := range [][]byte{nil, []byte("secret")} {
+		ast := assert.New(t)
+		p := NewPaginator(PaginatorParams{CursorSecret: secret})
+
+		token, err := p.EncodeCursor(&cursor{ID: 1, Name: "n"})
+		ast.NoError(err)
+
+		var c cursor
+		ast.NoError(p.DecodeCursor(token, &c))
+		ast.Equal(cursor{ID: 1, Name: "n"}, c)
+
+		for _, invalidToken := range []string{"!", "e30", token + "x"} {
+			if len(secret) == 0 && invalidToken == "e30" {
+				continue // e30 is `{}`
+			}
+			err = p.DecodeCursor(invalidToken, &c)
+			ast.ErrorIs(err, ErrInvalidCursor, invalidToken)
+			ast.True(errorx.IsCodeError(err), invalidToken)
+		}
+
+		_, err = p.EncodeCursor(func() {})
+		ast.Error(err)
+	}
+
+	p := NewPaginator(PaginatorParams{CursorSecret: []byte("secret")})
+	token, err := p.EncodeCursor(1)
+	assert.NoError(t, err)
+	var n int
+	assert.Error(t, NewPaginator(PaginatorParams{CursorSecret: []byte("other")}).DecodeCursor(token, &n))
+}
+
+func TestPaginatorLinks(t *testing.T) {
+	p := NewPaginator(PaginatorParams{})
+	r := httptest.NewRequest("GET", "http://localhost/users?page=2&pageSize=10&q=x", nil)
+
+	tests := []struct {
+		name     string
+		page     *OffsetPage
+		expected string
+	}{{
+		name: "middle",
+		page: NewOffsetPage([]int{1}, 35, &PageQuery{Page: 2, PageSize: 10}),
+		expected: `</users?page=1&pageSize=10&q=x>; rel="first", ` +
+			`</users?page=1&pageSize=10&q=x>; rel="prev", ` +
+			`</users?page=3&pageSize=10&q=x>; rel="next", ` +
+			`</users?page=4&pageSize=10&q=x>; rel="last"`,
+	}, {
+		name: "first",
+		page: NewOffsetPage([]int{1}, 35, &PageQuery{Page: 1, PageSize: 10}),
+		expected: `</users?page=1&pageSize=10&q=x>; rel="first", ` +
+			`</users?page=2&pageSize=10&q=x>; rel="next", ` +
+			`</users?page=4&pageSize=10&q=x>; rel="last"`,
+	}, {
+		name: "last",
+		page: NewOffsetPage([]int{1}, 35, &PageQuery{Page: 4, PageSize: 10}),
+		expected: `</users?page=1&pageSize=10&q=x>; rel="first", ` +
+			`</users?page=3&pageSize=10&q=x>; rel="prev", ` +
+			`</users?page=4&pageSize=10&q=x>; rel="last"`,
+	}, {
+		name:     "empty",
+		page:     NewOffsetPage(nil, 0, &PageQuery{Page: 1, PageSize: 10}),
+		expected: `</users?page=1&pageSize=10&q=x>; rel="first"`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			p.SetOffsetLinks(rec, r, test.page)
+			assert.Equal(t, test.expected, rec.Header().Get("Link"))
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	p.SetCursorLinks(rec, r, NewCursorPage([]int{1}, "n", "p"))
+	assert.Equal(t, `</users?cursor=p&page=2&pageSize=10&q=x>; rel="prev", `+
+		`</users?cursor=n&page=2&pageSize=10&q=x>; rel="next"`, rec.Header().Get("Link"))
+
+	rec = httptest.NewRecorder()
+	p.SetCursorLinks(rec, r, NewCursorPage([]int{1}, "", ""))
+	assert.Equal(t, "", rec.Header().Get("Link"))
+}
+
+func TestPageRender(t *testing.T) {
+	h := NewStandardHandler(StandardHandlerParams{})
+
+	rec := httptest.NewRecorder()
+	h.Handle(rec, httptest.NewRequest("GET", "http://localhost/users", nil),
+		NewOffsetPage(nil, 0, &PageQuery{Page: 1, PageSize: 10}), nil)
+	assert.JSONEq(t, `{"code":0,"message":"Success","data":{"items":[],"total":0,"page":1,"pageSize":10}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.Handle(rec, httptest.NewRequest("GET", "http://localhost/users", nil),
+		NewCursorPage([]string{"a"}, "n", ""), nil)
+	var body struct {
+		Data CursorPage `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, CursorPage{Items: []interface{}{"a"}, NextCursor: "n", HasMore: true}, body.Data)
+}
@@ -0,0 +1,244 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	_ PrometheusObserver = (*prometheusObserver)(nil)
+
+	DefaultPrometheusDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	DefaultPrometheusSizeBuckets     = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+const (
+	prometheusMetricResponsesTotal   = "http_responses_total"
+	prometheusMetricResponseDuration = "http_response_duration_seconds"
+	prometheusMetricResponseSize     = "http_response_size_bytes"
+
+	prometheusLabelValueSeparator = "\xff"
+)
+
+type (
+	// PrometheusObserver is an Observer which exposes the observations in Prometheus text format.
+	PrometheusObserver interface {
+		Observer
+		http.Handler
+	}
+
+	PrometheusObserverParams struct {
+		// Namespace is the prefix of the metric names, default is empty.
+		Namespace string
+		// DurationBuckets is the buckets of latency histogram in seconds, default is DefaultPrometheusDurationBuckets.
+		DurationBuckets []float64
+		// SizeBuckets is the buckets of response size histogram in bytes, default is DefaultPrometheusSizeBuckets.
+		SizeBuckets []float64
+	}
+
+	prometheusObserver struct {
+		params    PrometheusObserverParams
+		mu        sync.Mutex
+		responses map[string]*prometheusCounter
+		durations map[string]*prometheusHistogram
+		sizes     map[string]*prometheusHistogram
+	}
+
+	prometheusCounter struct {
+		labels []string
+		value  float64
+	}
+
+	prometheusHistogram struct {
+		labels []string
+		counts []uint64 // counts[i] is the count of the observations which less or equal than buckets[i]
+		count  uint64
+		sum    float64
+	}
+)
+
+// NewPrometheusObserver creates a PrometheusObserver.
+// The metrics are:
+//   - http_responses_total{route,method,status,code,category}
+//   - http_response_duration_seconds{route,method,status}, only for the requests with start time, see WithStartTime.
+//   - http_response_size_bytes{route,method,status}
+func NewPrometheusObserver(params PrometheusObserverParams) PrometheusObserver {
+	if len(params.DurationBuckets) == 0 {
+		params.DurationBuckets = DefaultPrometheusDurationBuckets
+	}
+	if len(params.SizeBuckets) == 0 {
+		params.SizeBuckets = DefaultPrometheusSizeBuckets
+	}
+	params.DurationBuckets = sortedBuckets(params.DurationBuckets)
+	params.SizeBuckets = sortedBuckets(params.SizeBuckets)
+
+	return &prometheusObserver{
+		params:    params,
+		responses: map[string]*prometheusCounter{},
+		durations: map[string]*prometheusHistogram{},
+		sizes:     map[string]*prometheusHistogram{},
+	}
+}
+
+func (p *prometheusObserver) Observe(_ context.Context, o *Observation) {
+	status := strconv.Itoa(o.HTTPStatus)
+	responseLabels := []string{o.Route, o.Method, status, strconv.Itoa(o.Code), strconv.Itoa(o.CategoryCode)}
+	histogramLabels := []string{o.Route, o.Method, status}
+	histogramKey := strings.Join(histogramLabels, prometheusLabelValueSeparator)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	responseKey := strings.Join(responseLabels, prometheusLabelValueSeparator)
+	counter, ok := p.responses[responseKey]
+	if !ok {
+		counter = &prometheusCounter{labels: responseLabels}
+		p.responses[responseKey] = counter
+	}
+	counter.value++
+
+	if o.Latency > 0 {
+		p.getHistogram(p.durations, histogramKey, histogramLabels, p.params.DurationBuckets).
+			observe(p.params.DurationBuckets, o.Latency.Seconds())
+	}
+	p.getHistogram(p.sizes, histogramKey, histogramLabels, p.params.SizeBuckets).
+		observe(p.params.SizeBuckets, float64(o.ResponseBytes))
+}
+
+func (p *prometheusObserver) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buff bytes.Buffer
+	p.writeTo(&buff)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buff.Bytes())
+}
+
+func (p *prometheusObserver) writeTo(buff *bytes.Buffer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := p.metricName(prometheusMetricResponsesTotal)
+	fmt.Fprintf(buff, "# HELP %s The total number of handled responses.\n", name)
+	fmt.Fprintf(buff, "# TYPE %s counter\n", name)
+	keys := make([]string, 0, len(p.responses))
+	for k := range p.responses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := p.responses[key]
+		fmt.Fprintf(buff, "%s%s %s\n", name,
+			formatPrometheusLabels([]string{"route", "method", "status", "code", "category"}, c.labels, "", ""),
+			formatPrometheusValue(c.value))
+	}
+
+	p.writeHistograms(buff, p.metricName(prometheusMetricResponseDuration),
+		"The latency of handled responses in seconds.", p.durations, p.params.DurationBuckets)
+	p.writeHistograms(buff, p.metricName(prometheusMetricResponseSize),
+		"The body size of handled responses in bytes.", p.sizes, p.params.SizeBuckets)
+}
+
+func (*prometheusObserver) writeHistograms(
+	buff *bytes.Buffer, name, help string, histograms map[string]*prometheusHistogram, buckets []float64,
+) {
+	labelNames := []string{"route", "method", "status"}
+
+	fmt.Fprintf(buff, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buff, "# TYPE %s histogram\n", name)
+	keys := make([]string, 0, len(histograms))
+	for k := range histograms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h := histograms[key]
+		for i, upperBound := range buckets {
+			fmt.Fprintf(buff, "%s_bucket%s %d\n", name,
+				formatPrometheusLabels(labelNames, h.labels, "le", formatPrometheusValue(upperBound)), h.counts[i])
+		}
+		fmt.Fprintf(buff, "%s_bucket%s %d\n", name, formatPrometheusLabels(labelNames, h.labels, "le", "+Inf"), h.count)
+		fmt.Fprintf(buff, "%s_sum%s %s\n", name, formatPrometheusLabels(labelNames, h.labels, "", ""), formatPrometheusValue(h.sum))
+		fmt.Fprintf(buff, "%s_count%s %d\n", name, formatPrometheusLabels(labelNames, h.labels, "", ""), h.count)
+	}
+}
+
+func (p *prometheusObserver) metricName(name string) string {
+	if p.params.Namespace == "" {
+		return name
+	}
+	return p.params.Namespace + "_" + name
+}
+
+func (*prometheusObserver) getHistogram(
+	histograms map[string]*prometheusHistogram, key string, labels []string, buckets []float64,
+) *prometheusHistogram {
+	h, ok := histograms[key]
+	if !ok {
+		h = &prometheusHistogram{
+			labels: labels,
+			counts: make([]uint64, len(buckets)),
+		}
+		histograms[key] = h
+	}
+	return h
+}
+
+func (h *prometheusHistogram) observe(buckets []float64, v float64) {
+	for i, upperBound := range buckets {
+		if v <= upperBound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	cpy := make([]float64, len(buckets))
+	copy(cpy, buckets)
+	sort.Float64s(cpy)
+	return cpy
+}
+
+func formatPrometheusLabels(names, values []string, extraName, extraValue string) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapePrometheusLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extraName)
+		sb.WriteString(`="`)
+		sb.WriteString(extraValue)
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func escapePrometheusLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v)
+}
+
+func formatPrometheusValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
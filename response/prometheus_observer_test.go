@@ -0,0 +1,95 @@
+package response
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusObserver(t *testing.T) {
+	ast := assert.New(t)
+
+	p := NewPrometheusObserver(PrometheusObserverParams{
+		Namespace:       "test",
+		DurationBuckets: []float64{1, 0.1},
+		SizeBuckets:     []float64{10, 100},
+	})
+
+	p.Observe(context.Background(), &Observation{
+		Route:         "/users/{id}",
+		Method:        "GET",
+		HTTPStatus:    200,
+		Latency:       50 * time.Millisecond,
+		ResponseBytes: 30,
+	})
+	p.Observe(context.Background(), &Observation{
+		Route:         "/users/{id}",
+		Method:        "GET",
+		HTTPStatus:    200,
+		Latency:       500 * time.Millisecond,
+		ResponseBytes: 5,
+	})
+	p.Observe(context.Background(), &Observation{
+		Route:         `/"quoted"`,
+		Method:        "POST",
+		HTTPStatus:    404,
+		Code:          40401001,
+		CategoryCode:  404,
+		ResponseBytes: 500,
+	})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/metrics", nil))
+	ast.Equal(200, rec.Code)
+	ast.Equal("text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body, err := io.ReadAll(rec.Body)
+	ast.NoError(err)
+	ast.Equal(strings.Join([]string{
+		`# HELP test_http_responses_total The total number of handled responses.`,
+		`# TYPE test_http_responses_total counter`,
+		`test_http_responses_total{route="/\"quoted\"",method="POST",status="404",code="40401001",category="404"} 1`,
+		`test_http_responses_total{route="/users/{id}",method="GET",status="200",code="0",category="0"} 2`,
+		`# HELP test_http_response_duration_seconds The latency of handled responses in seconds.`,
+		`# TYPE test_http_response_duration_seconds histogram`,
+		`test_http_response_duration_seconds_bucket{route="/users/{id}",method="GET",status="200",le="0.1"} 1`,
+		`test_http_response_duration_seconds_bucket{route="/users/{id}",method="GET",status="200",le="1"} 2`,
+		`test_http_response_duration_seconds_bucket{route="/users/{id}",method="GET",status="200",le="+Inf"} 2`,
+		`test_http_response_duration_seconds_sum{route="/users/{id}",method="GET",status="200"} 0.55`,
+		`test_http_response_duration_seconds_count{route="/users/{id}",method="GET",status="200"} 2`,
+		`# HELP test_http_response_size_bytes The body size of handled responses in bytes.`,
+		`# TYPE test_http_response_size_bytes histogram`,
+		`test_http_response_size_bytes_bucket{route="/\"quoted\"",method="POST",status="404",le="10"} 0`,
+		`test_http_response_size_bytes_bucket{route="/\"quoted\"",method="POST",status="404",le="100"} 0`,
+		`test_http_response_size_bytes_bucket{route="/\"quoted\"",method="POST",status="404",le="+Inf"} 1`,
+		`test_http_response_size_bytes_sum{route="/\"quoted\"",method="POST",status="404"} 500`,
+		`test_http_response_size_bytes_count{route="/\"quoted\"",method="POST",status="404"} 1`,
+		`test_http_response_size_bytes_bucket{route="/users/{id}",method="GET",status="200",le="10"} 1`,
+		`test_http_response_size_bytes_bucket{route="/users/{id}",method="GET",status="200",le="100"} 2`,
+		`test_http_response_size_bytes_bucket{route="/users/{id}",method="GET",status="200",le="+Inf"} 2`,
+		`test_http_response_size_bytes_sum{route="/users/{id}",method="GET",status="200"} 35`,
+		`test_http_response_size_bytes_count{route="/users/{id}",method="GET",status="200"} 2`,
+		``,
+	}, "\n"), string(body))
+}
+
+func TestPrometheusObserverDefault(t *testing.T) {
+	ast := assert.New(t)
+
+	h := NewStandardHandler(StandardHandlerParams{
+		Observer: NewPrometheusObserver(PrometheusObserverParams{}),
+	})
+	h.Handle(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost/users/1", nil), nil, nil)
+
+	rec := httptest.NewRecorder()
+	h.(*standardHandler).params.Observer.(PrometheusObserver).ServeHTTP(rec, httptest.NewRequest("GET", "http://localhost/metrics", nil))
+	ast.Contains(rec.Body.String(), `http_responses_total{route="unmatched",method="GET",status="200",code="0",category="0"} 1`)
+	ast.Contains(rec.Body.String(), `http_response_size_bytes_bucket{route="unmatched",method="GET",status="200",le="100"} 1`)
+	ast.Contains(rec.Body.String(), `http_response_size_bytes_bucket{route="unmatched",method="GET",status="200",le="1e+07"} 1`)
+	ast.NotContains(rec.Body.String(), `http_response_duration_seconds_bucket`)
+}
@@ -0,0 +1,157 @@
+// Package responsetest provides utilities for testing the handlers which use the standard response.Handler.
+package responsetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+	"github.com/vesoft-inc/go-pkg/response"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("responsetest.update", false, "update the golden files of responsetest")
+
+type (
+	// Envelope is the body written by the standard response.Handler.
+	Envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data,omitempty"`
+		Details string          `json:"details,omitempty"`
+	}
+
+	// Result is the recorded result of a handler.
+	Result struct {
+		Recorder   *httptest.ResponseRecorder
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+		// Envelope is the decoded body, it's nil if the body is not a standard envelope.
+		Envelope *Envelope
+	}
+)
+
+// Do runs the handler h against r, and decodes the standard envelope.
+func Do(t testing.TB, h http.Handler, r *http.Request) *Result {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return newResult(rec)
+}
+
+// Handle runs the response.Handler h with data and err against r, and decodes the standard envelope.
+func Handle(t testing.TB, h response.Handler, r *http.Request, data interface{}, err error) *Result {
+	t.Helper()
+
+	return Do(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Handle(w, r, data, err)
+	}), r)
+}
+
+// DecodeData decodes the data field into v, v is untouched if the data is empty.
+// It fails if the body is not a standard envelope.
+func (r *Result) DecodeData(v interface{}) error {
+	if r.Envelope == nil {
+		return errors.Errorf("body is not a standard envelope: %s", r.Body)
+	}
+	if len(r.Envelope.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Envelope.Data, v)
+}
+
+// AssertSuccess asserts that the result is succeeded with the data equal to expectedData in json.
+// The nil expectedData means the data field is absent.
+func AssertSuccess(t testing.TB, res *Result, expectedData interface{}) bool {
+	t.Helper()
+
+	if !assert.Equal(t, http.StatusOK, res.StatusCode, "http status, body: %s", res.Body) ||
+		!assert.NotNil(t, res.Envelope, "standard envelope, body: %s", res.Body) ||
+		!assert.Equal(t, 0, res.Envelope.Code, "code, body: %s", res.Body) {
+		return false
+	}
+
+	if expectedData == nil {
+		return assert.Empty(t, res.Envelope.Data, "data")
+	}
+
+	expected, err := json.Marshal(expectedData)
+	if !assert.NoError(t, err, "json.Marshal expected data") {
+		return false
+	}
+	return assert.JSONEq(t, string(expected), string(res.Envelope.Data), "data")
+}
+
+// AssertError asserts that the result is failed with the code c and the http status.
+func AssertError(t testing.TB, res *Result, c *errorx.ErrCode, httpStatus int) bool {
+	t.Helper()
+
+	if !assert.Equal(t, httpStatus, res.StatusCode, "http status, body: %s", res.Body) ||
+		!assert.NotNil(t, res.Envelope, "standard envelope, body: %s", res.Body) {
+		return false
+	}
+	return assert.Equal(t, c.GetCode(), res.Envelope.Code, "code, body: %s", res.Body) &&
+		assert.Equal(t, c.GetMessage(), res.Envelope.Message, "message, body: %s", res.Body) &&
+		assert.Empty(t, res.Envelope.Data, "data")
+}
+
+// AssertGolden asserts that the body is equal to the golden file.
+// The json body is compared in indented format.
+// Run tests with -responsetest.update to create or update the golden files.
+func AssertGolden(t testing.TB, res *Result, filename string) bool {
+	t.Helper()
+
+	actual := res.Body
+	var buff bytes.Buffer
+	if json.Valid(actual) && json.Indent(&buff, actual, "", "  ") == nil {
+		buff.WriteByte('\n')
+		actual = buff.Bytes()
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); !assert.NoError(t, err) {
+			return false
+		}
+		if err := os.WriteFile(filename, actual, 0o600); !assert.NoError(t, err) {
+			return false
+		}
+	}
+
+	expected, err := os.ReadFile(filename)
+	if !assert.NoError(t, err, "read golden file, run tests with -responsetest.update to create it") {
+		return false
+	}
+	return assert.Equal(t, string(expected), string(actual), "golden file %s", filename)
+}
+
+func newResult(rec *httptest.ResponseRecorder) *Result {
+	res := &Result{
+		Recorder:   rec,
+		StatusCode: rec.Code,
+		Header:     rec.Header(),
+		Body:       rec.Body.Bytes(),
+	}
+
+	var envelope struct {
+		Envelope
+		Code    *int    `json:"code"`
+		Message *string `json:"message"`
+	}
+	if err := json.Unmarshal(res.Body, &envelope); err == nil && envelope.Code != nil && envelope.Message != nil {
+		envelope.Envelope.Code = *envelope.Code
+		envelope.Envelope.Message = *envelope.Message
+		res.Envelope = &envelope.Envelope
+	}
+
+	return res
+}
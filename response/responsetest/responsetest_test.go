@@ -0,0 +1,137 @@
+package responsetest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vesoft-inc/go-pkg/errorx"
+	"github.com/vesoft-inc/go-pkg/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	testTB struct {
+		testing.TB
+		failed bool
+	}
+
+	testUser struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+)
+
+var testErrNotFound = errorx.NewErrCode(errorx.CCNotFound, 1, 1, "ErrUserNotFound")
+
+func (t *testTB) Errorf(string, ...interface{}) {
+	t.failed = true
+}
+
+func TestDo(t *testing.T) {
+	ast := assert.New(t)
+
+	h := response.NewStandardHandler(response.StandardHandlerParams{})
+	res := Do(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Handle(w, r, &testUser{ID: 1, Name: "n"}, nil)
+	}), httptest.NewRequest(http.MethodGet, "http://localhost/users/1", nil))
+
+	ast.Equal(http.StatusOK, res.StatusCode)
+	ast.Equal("application/json", res.Header.Get("Content-Type"))
+	if ast.NotNil(res.Envelope) {
+		ast.Equal(0, res.Envelope.Code)
+		ast.Equal("Success", res.Envelope.Message)
+	}
+	var user testUser
+	ast.NoError(res.DecodeData(&user))
+	ast.Equal(testUser{ID: 1, Name: "n"}, user)
+
+	res = Do(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"k":"v"}`))
+	}), httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	ast.Nil(res.Envelope)
+	ast.EqualError(res.DecodeData(&user), `body is not a standard envelope: {"k":"v"}`)
+
+	res = Handle(t, h, httptest.NewRequest(http.MethodGet, "http://localhost", nil), nil, nil)
+	if ast.NotNil(res.Envelope) {
+		ast.Empty(res.Envelope.Data)
+	}
+	ast.NoError(res.DecodeData(&user))
+}
+
+func TestAssertSuccess(t *testing.T) {
+	ast := assert.New(t)
+	h := response.NewStandardHandler(response.StandardHandlerParams{})
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/users/1", nil)
+
+	res := Handle(t, h, r, &testUser{ID: 1, Name: "n"}, nil)
+	ast.True(AssertSuccess(t, res, testUser{ID: 1, Name: "n"}))
+	ast.True(AssertSuccess(t, res, map[string]interface{}{"id": 1, "name": "n"}))
+
+	tb := &testTB{TB: t}
+	ast.False(AssertSuccess(tb, res, testUser{ID: 2, Name: "n"}))
+	ast.True(tb.failed)
+
+	tb = &testTB{TB: t}
+	ast.False(AssertSuccess(tb, res, nil))
+	ast.True(tb.failed)
+
+	res = Handle(t, h, r, nil, nil)
+	ast.True(AssertSuccess(t, res, nil))
+
+	tb = &testTB{TB: t}
+	ast.False(AssertSuccess(tb, Handle(t, h, r, nil, errors.New("testError")), nil))
+	ast.True(tb.failed)
+}
+
+func TestAssertError(t *testing.T) {
+	ast := assert.New(t)
+	h := response.NewStandardHandler(response.StandardHandlerParams{})
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/users/1", nil)
+
+	res := Handle(t, h, r, nil, errorx.WithCode(testErrNotFound, nil))
+	ast.True(AssertError(t, res, testErrNotFound, http.StatusNotFound))
+
+	tb := &testTB{TB: t}
+	ast.False(AssertError(tb, res, testErrNotFound, http.StatusBadRequest))
+	ast.True(tb.failed)
+
+	tb = &testTB{TB: t}
+	ast.False(AssertError(tb, res, errorx.NewErrCode(errorx.CCNotFound, 1, 2, "ErrOther"), http.StatusNotFound))
+	ast.True(tb.failed)
+
+	tb = &testTB{TB: t}
+	ast.False(AssertError(tb, Handle(t, h, r, "data", nil), testErrNotFound, http.StatusOK))
+	ast.True(tb.failed)
+}
+
+func TestAssertGolden(t *testing.T) {
+	ast := assert.New(t)
+	h := response.NewStandardHandler(response.StandardHandlerParams{})
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/users/1", nil)
+
+	res := Handle(t, h, r, &testUser{ID: 1, Name: "n"}, nil)
+	ast.True(AssertGolden(t, res, filepath.Join("testdata", "user.golden.json")))
+
+	tb := &testTB{TB: t}
+	ast.False(AssertGolden(tb, Handle(t, h, r, &testUser{ID: 2, Name: "n"}, nil), filepath.Join("testdata", "user.golden.json")))
+	ast.True(tb.failed)
+
+	tb = &testTB{TB: t}
+	ast.False(AssertGolden(tb, res, filepath.Join("testdata", "not-exists.golden.json")))
+	ast.True(tb.failed)
+
+	*update = true
+	defer func() {
+		*update = false
+	}()
+	filename := filepath.Join(t.TempDir(), "dir", "user.golden.json")
+	ast.True(AssertGolden(t, res, filename))
+	bs, err := os.ReadFile(filename)
+	ast.NoError(err)
+	ast.Equal("{\n  \"code\": 0,\n  \"data\": {\n    \"id\": 1,\n    \"name\": \"n\"\n  },\n  \"message\": \"Success\"\n}\n", string(bs))
+}
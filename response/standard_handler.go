@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/vesoft-inc/go-pkg/errorx"
 )
@@ -51,6 +52,16 @@ type (
 		ContextErrorf func(ctx context.Context, format string, a ...interface{})
 		// DetailsType is the type for details field, default is StandardHandlerDetailsDisable.
 		DetailsType StandardHandlerDetailsType
+		// Observer observes every handled response, default is nil.
+		Observer Observer
+		// GetRoute returns the route pattern of the request for Observer such as /users/{id},
+		// it must not return the raw url path which has unbounded values. Default is UnmatchedRoute.
+		GetRoute func(r *http.Request) string
+		// ETag enables to compute a strong ETag over the encoded body of GET and HEAD requests, default is false.
+		// The ETag can also be supplied by StandardHandlerDataWithCacheValidators.
+		ETag bool
+		// GetCachePolicy returns the policy of Cache-Control header for GET and HEAD requests, default is nil.
+		GetCachePolicy func(r *http.Request) *CachePolicy
 	}
 
 	standardHandlerDataFieldAny struct {
@@ -108,6 +119,13 @@ func GetStandardHandlerDataFieldAnyData(data interface{}) (interface{}, bool) {
 }
 
 func (h *standardHandler) GetStatusBody(r *http.Request, data interface{}, err error) (httpStatus int, body interface{}) {
+	httpStatus, body, _ = h.getStatusBody(r, data, err)
+	return httpStatus, body
+}
+
+func (h *standardHandler) getStatusBody(r *http.Request, data interface{}, err error) (
+	httpStatus int, body interface{}, codeErr errorx.CodeError,
+) {
 	httpStatus = http.StatusOK
 	bodyType := StandardHandlerBodyJson
 
@@ -130,6 +148,7 @@ func (h *standardHandler) GetStatusBody(r *http.Request, data interface{}, err e
 			}), err)
 			e, _ = errorx.AsCodeError(err)
 		}
+		codeErr = e
 
 		httpStatus = e.GetHTTPStatus()
 
@@ -165,35 +184,83 @@ func (h *standardHandler) GetStatusBody(r *http.Request, data interface{}, err e
 		body = resp
 	}
 
-	return httpStatus, body
+	return httpStatus, body, codeErr
 }
 
 func (h *standardHandler) Handle(w http.ResponseWriter, r *http.Request, data interface{}, err error) {
-	httpStatus, body := h.GetStatusBody(r, data, err)
+	_, validators, _ := GetStandardHandlerDataCacheValidators(data)
+	httpStatus, body, codeErr := h.getStatusBody(r, data, err)
+	httpStatus, n := h.write(w, r, httpStatus, body, validators)
+	h.observe(r, httpStatus, n, codeErr, err)
+}
+
+func (h *standardHandler) write(
+	w http.ResponseWriter, r *http.Request, httpStatus int, body interface{}, validators *CacheValidators,
+) (writtenStatus, writtenBytes int) {
 	if body == nil {
 		w.WriteHeader(httpStatus)
-		return
+		return httpStatus, 0
 	}
 
 	bs, err := json.Marshal(body)
 	if err != nil {
 		h.errorf(r, "write response json.Marshal failed, error: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return http.StatusInternalServerError, 0
+	}
+
+	if httpStatus == http.StatusOK && h.writeCacheHeaders(w, r, validators, bs) {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified, 0
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
-	if n, err := w.Write(bs); err != nil {
+	n, err := w.Write(bs)
+	if err != nil {
 		if err != http.ErrHandlerTimeout {
 			h.errorf(r, "write response failed, error: %s", err)
 		}
 	} else if n < len(bs) {
 		h.errorf(r, "write response failed, actual bytes: %d, written bytes: %d", len(bs), n)
 	}
+	return httpStatus, n
+}
+
+func (h *standardHandler) observe(r *http.Request, httpStatus, n int, codeErr errorx.CodeError, err error) {
+	if h.params.Observer == nil {
+		return
+	}
+
+	o := &Observation{
+		Request:       r,
+		Route:         UnmatchedRoute,
+		HTTPStatus:    httpStatus,
+		ResponseBytes: n,
+		Err:           err,
+	}
+	if codeErr != nil {
+		o.Code = codeErr.GetCode()
+		o.CategoryCode = codeErr.GetCategoryCode()
+	}
+
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+		o.Method = r.Method
+		if h.params.GetRoute != nil {
+			o.Route = h.params.GetRoute(r)
+		}
+	}
+	if startTime, ok := GetStartTime(ctx); ok {
+		o.Latency = time.Since(startTime)
+	}
+
+	h.params.Observer.Observe(ctx, o)
 }
 
 func (*standardHandler) getData(data interface{}) interface{} {
+	data, _, _ = GetStandardHandlerDataCacheValidators(data)
 	if isInterfaceNil(data) {
 		return nil
 	}